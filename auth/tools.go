@@ -22,7 +22,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func GetUserID(ctx *gin.Context, rdb *engine.ReportsDatabase) (int, error) {
+func GetUserID(ctx *gin.Context, store engine.ReportStore) (int, error) {
 	ctxUserID, exists := ctx.Get("userID")
 	if exists {
 		userID, ok := ctxUserID.(string)
@@ -37,7 +37,7 @@ func GetUserID(ctx *gin.Context, rdb *engine.ReportsDatabase) (int, error) {
 		if !ok {
 			return 0, fmt.Errorf("user name has to be string")
 		}
-		return rdb.GetUserID(userName)
+		return store.GetUserID(userName)
 	}
 	return 0, fmt.Errorf("user ID or user name not found")
 }
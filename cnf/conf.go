@@ -20,12 +20,15 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/czcorpus/cnc-gokit/logging"
 	"github.com/czcorpus/conomi/auth"
 	"github.com/czcorpus/conomi/engine"
+	"github.com/czcorpus/conomi/escalator"
 	"github.com/czcorpus/conomi/notifiers/common"
+	"github.com/czcorpus/conomi/retention"
 	"github.com/rs/zerolog/log"
 )
 
@@ -50,6 +53,8 @@ type Conf struct {
 	Notifiers              []common.NotifierConf `json:"notifiers"`
 	PublicPath             string                `json:"publicPath"`
 	Auth                   *auth.AuthConf        `json:"auth"`
+	Retention              *retention.Conf       `json:"retention"`
+	Escalation             *escalator.Conf       `json:"escalation"`
 
 	srcPath string
 }
@@ -125,6 +130,34 @@ func ValidateAndDefaults(conf *Conf) {
 	if _, err := time.LoadLocation(conf.TimeZone); err != nil {
 		log.Fatal().Err(err).Msg("invalid time zone")
 	}
+	if conf.DB == nil {
+		log.Fatal().Msg("missing database configuration")
+	}
+	if conf.DB.Driver == "" {
+		conf.DB.Driver = engine.DriverMySQL
+		log.Warn().
+			Str("default", conf.DB.Driver).
+			Msg("database driver not specified, using default")
+	}
+	if conf.Retention != nil {
+		if err := conf.Retention.ValidateAndDefaults(); err != nil {
+			log.Fatal().Err(err).Msg("invalid retention configuration")
+		}
+	}
+	if conf.Escalation == nil {
+		conf.Escalation = &escalator.Conf{}
+		log.Warn().Msg("escalation not specified, using default policy")
+	}
+	if err := conf.Escalation.ValidateAndDefaults(); err != nil {
+		log.Fatal().Err(err).Msg("invalid escalation configuration")
+	}
+	for i, tier := range conf.Escalation.Tiers {
+		for _, name := range tier.Notifiers {
+			if !slices.ContainsFunc(conf.Notifiers, func(n common.NotifierConf) bool { return n.Name == name }) {
+				log.Fatal().Msgf("invalid escalation tier %d: unknown notifier `%s`", i+1, name)
+			}
+		}
+	}
 	for _, notifier := range conf.Notifiers {
 		if err := notifier.Filter.Validate(); err != nil {
 			log.Fatal().Err(err).Msg("invalid filter")
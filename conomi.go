@@ -19,7 +19,6 @@ package main
 import (
 	"context"
 	"crypto/sha256"
-	"database/sql"
 	"flag"
 	"fmt"
 	"html/template"
@@ -36,8 +35,10 @@ import (
 	"github.com/czcorpus/conomi/engine"
 	"github.com/czcorpus/conomi/escalator"
 	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/maintenance"
 	"github.com/czcorpus/conomi/notifiers"
 	"github.com/czcorpus/conomi/reporting"
+	"github.com/czcorpus/conomi/retention"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 
@@ -55,7 +56,7 @@ func runApiServer(
 	conf *cnf.Conf,
 	syscallChan chan os.Signal,
 	exitEvent chan os.Signal,
-	sqlDB *sql.DB,
+	store engine.ReportStore,
 ) error {
 	if !conf.Logging.Level.IsDebugMode() {
 		gin.SetMode(gin.ReleaseMode)
@@ -72,11 +73,24 @@ func runApiServer(
 	if err != nil {
 		return fmt.Errorf("failed to instantiate notifiers: %w", err)
 	}
-	e, err := escalator.NewEscalator(sqlDB, n)
+	e, err := escalator.NewEscalator(conf.Escalation, store, n)
 	if err != nil {
 		return fmt.Errorf("failed to instantiate escalator: %w", err)
 	}
-	r := reporting.NewActions(conf.TimezoneLocation(), sqlDB, n, e)
+	n.SetSnoozer(e)
+	m, err := maintenance.NewSchedule(store, conf.TimezoneLocation())
+	if err != nil {
+		return fmt.Errorf("failed to instantiate maintenance schedule: %w", err)
+	}
+	e.SetMaintenance(m)
+	e.Start()
+	defer e.Stop()
+	if conf.Retention != nil {
+		janitor := retention.NewJanitor(conf.Retention, store, conf.TimezoneLocation(), e)
+		janitor.Start()
+		defer janitor.Stop()
+	}
+	r := reporting.NewActions(conf.TimezoneLocation(), store, n, e, m)
 	api := engine.Group("/api")
 	api.Use(uniresp.AlwaysJSONContentType())
 	api.Use(auth.AbortUnauthorized())
@@ -85,8 +99,28 @@ func runApiServer(
 	api.GET("/report/:reportId", r.GetReport)
 	api.POST("/resolve/:groupId", r.ResolveGroup)
 	api.GET("/reports", r.GetReports)
+	api.GET("/search", r.Search)
 	api.GET("/sources", r.GetSources)
+	api.GET("/sources/snoozes", r.GetSourceSnoozes)
+	api.POST("/sources/snooze", r.SnoozeSource)
+	api.POST("/sources/unsnooze", r.UnsnoozeSource)
 	api.GET("/overview", r.GetOverview)
+	api.GET("/maintenance", r.GetMaintenanceWindows)
+	api.POST("/maintenance", r.PostMaintenanceWindow)
+	api.DELETE("/maintenance/:windowId", r.DeleteMaintenanceWindow)
+	api.GET("/groups", r.GetGroups)
+	api.GET("/groups/:groupId", r.GetGroup)
+	api.GET("/groups/:groupId/reports", r.GetGroupReports)
+	api.GET("/groups/:groupId/events", r.GetGroupEvents)
+	api.POST("/groups/:groupId/comments", r.PostGroupComment)
+	api.POST("/groups/:groupId/acknowledge", r.AcknowledgeGroup)
+	api.POST("/groups/:groupId/assign", r.AssignGroup)
+	api.POST("/groups/:groupId/unassign", r.UnassignGroup)
+	api.POST("/groups/:groupId/snooze", r.SnoozeGroup)
+	api.POST("/groups/:groupId/unsnooze", r.UnsnoozeGroup)
+	api.POST("/groups/:groupId/reopen", r.ReopenGroup)
+	api.POST("/groups/:groupId/merge", r.MergeGroups)
+	api.POST("/groups/:groupId/split", r.SplitGroup)
 
 	engine.LoadHTMLFiles(filepath.Join(conf.ClientDistDirPath, "index.html"))
 	ui := engine.Group("/ui")
@@ -153,16 +187,86 @@ func runApiServer(
 	return nil
 }
 
+func runMigrate(conf *cnf.Conf, subAction string) error {
+	migrator, err := engine.NewMigrator(conf.DB)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+	switch subAction {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		for _, mig := range applied {
+			fmt.Printf("applied %s\n", mig.Name)
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		reverted, err := migrator.Down()
+		if err != nil {
+			return err
+		}
+		if reverted != nil {
+			fmt.Printf("reverted %s\n", reverted.Name)
+
+		} else {
+			fmt.Println("nothing to revert")
+		}
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("current schema version: %d\n", status.Current)
+		fmt.Printf("latest schema version: %d\n", status.Latest)
+		for _, mig := range status.Pending {
+			fmt.Printf("pending: %s\n", mig.Name)
+		}
+	default:
+		return fmt.Errorf("unknown migrate action `%s`, use `up`, `down` or `status`", subAction)
+	}
+	return nil
+}
+
+func runPurge(conf *cnf.Conf, dryRun bool) error {
+	if conf.Retention == nil {
+		return fmt.Errorf("no retention policy configured")
+	}
+	store, err := engine.Open(conf.DB)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	janitor := retention.NewJanitor(conf.Retention, store, conf.TimezoneLocation(), nil)
+	result, err := janitor.Purge(dryRun)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		fmt.Printf("reports to be purged: %d\n", result.DeletedReports)
+		fmt.Printf("groups to be purged: %d\n", result.DeletedGroups)
+	} else {
+		fmt.Printf("purged reports: %d\n", result.DeletedReports)
+		fmt.Printf("purged groups: %d\n", result.DeletedGroups)
+	}
+	return nil
+}
+
 func main() {
 	build := general.Build{
 		Version:   version,
 		BuildDate: buildDate,
 		GitCommit: gitCommit,
 	}
-	createTables := flag.Bool("create-tables", false, "Force creating db tables (requires proper permissions)")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Conomi - CNC Notification Middleware\n\n")
 		fmt.Fprintf(os.Stderr, "Usage:\n\t%s [options] start [config.json]\n\t", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "%s [options] migrate [up|down|status] [config.json]\n\t", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "%s [options] purge [--dry-run] [config.json]\n\t", filepath.Base(os.Args[0]))
 		fmt.Fprintf(os.Stderr, "%s hashtoken [token]\n\t", filepath.Base(os.Args[0]))
 		fmt.Fprintf(os.Stderr, "%s [options] version\n", filepath.Base(os.Args[0]))
 		flag.PrintDefaults()
@@ -182,12 +286,24 @@ func main() {
 		fmt.Printf("%x\n", h.Sum(nil))
 		return
 
-	} else if action != "test" && action != "start" {
+	} else if action != "test" && action != "start" && action != "migrate" && action != "purge" {
 		fmt.Println("unknown action ", action)
 		os.Exit(1)
 		return
 	}
-	conf := cnf.LoadConfig(flag.Arg(1))
+	confPath := flag.Arg(1)
+	var purgeDryRun bool
+	switch action {
+	case "migrate":
+		confPath = flag.Arg(2)
+	case "purge":
+		purgeFlags := flag.NewFlagSet("purge", flag.ExitOnError)
+		dryRun := purgeFlags.Bool("dry-run", false, "Only count reports to be purged")
+		purgeFlags.Parse(flag.Args()[1:])
+		purgeDryRun = *dryRun
+		confPath = purgeFlags.Arg(0)
+	}
+	conf := cnf.LoadConfig(confPath)
 	info := general.GeneralInfo{
 		Build:      build,
 		PublicPath: conf.PublicPath,
@@ -216,14 +332,22 @@ func main() {
 
 	switch action {
 	case "start":
-		sqlDB, err := engine.Open(conf.DB, *createTables)
+		store, err := engine.Open(conf.DB)
 		if err != nil {
 			log.Fatal().Err(err).Msg("failed to open database connection")
 		}
-		err = runApiServer(info, conf, syscallChan, exitEvent, sqlDB)
+		err = runApiServer(info, conf, syscallChan, exitEvent, store)
 		if err != nil {
 			log.Fatal().Err(err).Msg("failed to run api server")
 		}
+	case "migrate":
+		if err := runMigrate(conf, flag.Arg(1)); err != nil {
+			log.Fatal().Err(err).Msg("failed to migrate database schema")
+		}
+	case "purge":
+		if err := runPurge(conf, purgeDryRun); err != nil {
+			log.Fatal().Err(err).Msg("failed to purge reports")
+		}
 	default:
 		log.Fatal().Msgf("Unknown action %s", action)
 	}
@@ -0,0 +1,90 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// sqlExecutor is implemented by both *sql.DB and *sql.Tx
+type sqlExecutor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// sqlDialect covers differences between supported SQL engines
+type sqlDialect interface {
+
+	// migrationsDir provides a name of a directory (within
+	// the embedded `migrations`) with the engine's schema migrations
+	migrationsDir() string
+
+	// schemaVersionTable provides a statement creating (if not exists)
+	// the table with applied schema migrations
+	schemaVersionTable() string
+
+	// rebind converts a query written with `?` placeholders
+	// to the form required by the driver
+	rebind(query string) string
+
+	// insert runs an INSERT query and returns ID of the new row
+	insert(db sqlExecutor, query string, args ...any) (int64, error)
+
+	// userTable provides a (quoted if necessary) name of the table
+	// with users
+	userTable() string
+
+	// timeCmp provides a condition comparing a datetime column
+	// (using the `op` operator) with a time passed as a query argument
+	timeCmp(column, op string) string
+
+	// hasFullText tells whether the engine provides full-text search
+	// (`MATCH ... AGAINST`) on report subjects and bodies. If not,
+	// the `conomi_report_token` table is used instead.
+	hasFullText() bool
+
+	// jsonValue provides an expression extracting a (textual) value
+	// from a JSON column along with a query argument representing
+	// the path
+	jsonValue(column string, path []string) (string, any)
+
+	// recentCond provides a condition matching values
+	// of a datetime column newer than one day
+	recentCond(column string) string
+
+	// transactionalDDL tells whether schema changes can be rolled back.
+	// If not (MySQL commits each DDL statement implicitly), a failed
+	// migration may leave some of its statements applied.
+	transactionalDDL() bool
+
+	// isAppliedSchemaChange tells whether a migration statement failed
+	// just because its change is already present (e.g. a duplicate
+	// column left by a previous, partially applied run)
+	isAppliedSchemaChange(err error) bool
+}
+
+// jsonPath creates a JSON path (`$."a"."b"`) as used by MySQL and SQLite
+func jsonPath(path []string) string {
+	var ans strings.Builder
+	ans.WriteString("$")
+	for _, key := range path {
+		ans.WriteString(`."` + key + `"`)
+	}
+	return ans.String()
+}
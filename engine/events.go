@@ -0,0 +1,86 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/rs/zerolog/log"
+)
+
+// recordGroupEvent adds a new item to a group timeline. The `db` argument
+// allows the event to be recorded as a part of a running transaction.
+func (rdb *ReportsDatabase) recordGroupEvent(db sqlExecutor, event *general.GroupEvent) error {
+	if event.Created.IsZero() {
+		event.Created = time.Now()
+	}
+	userID := sql.NullInt32{Int32: int32(event.UserID), Valid: event.UserID >= 0}
+	body := sql.NullString{String: event.Body, Valid: event.Body != ""}
+	sql1 := "INSERT INTO conomi_group_event (report_group_id, event_type, user_id, body, created) VALUES (?,?,?,?,?)"
+	log.Debug().Str("sql", sql1).Msgf("going to INSERT conomi_group_event WHERE report_group_id = %d", event.GroupID)
+	eventID, err := rdb.dialect.insert(db, sql1, event.GroupID, event.Type, userID, body, event.Created)
+	if err != nil {
+		return err
+	}
+	event.ID = int(eventID)
+	return nil
+}
+
+// AddGroupEvent adds a new item (e.g. a comment) to a group timeline.
+// Once inserted, the event ID and creation time are set.
+func (rdb *ReportsDatabase) AddGroupEvent(event *general.GroupEvent) error {
+	if err := rdb.recordGroupEvent(rdb.db, event); err != nil {
+		return fmt.Errorf("failed to add group event: %w", err)
+	}
+	return nil
+}
+
+// ListGroupEvents provides a timeline of a group ordered from
+// the oldest event
+func (rdb *ReportsDatabase) ListGroupEvents(groupID int) ([]*general.GroupEvent, error) {
+	sql1 := "SELECT cge.id, cge.report_group_id, cge.event_type, cge.user_id, us.user, cge.body, cge.created " +
+		"FROM conomi_group_event AS cge " +
+		"LEFT JOIN " + rdb.dialect.userTable() + " AS us ON cge.user_id = us.id " +
+		"WHERE cge.report_group_id = ? " +
+		"ORDER BY cge.created, cge.id"
+	log.Debug().Str("sql", sql1).Msgf("going to SELECT conomi_group_event WHERE report_group_id = %d", groupID)
+	rows, err := rdb.query(sql1, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group events: %w", err)
+	}
+	defer rows.Close()
+	ans := make([]*general.GroupEvent, 0, 20)
+	for rows.Next() {
+		event := &general.GroupEvent{UserID: -1}
+		var userID sql.NullInt32
+		var userName, body sql.NullString
+		var created sqlTime
+		err := rows.Scan(&event.ID, &event.GroupID, &event.Type, &userID, &userName, &body, &created)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list group events: %w", err)
+		}
+		if userID.Valid {
+			event.UserID = int(userID.Int32)
+		}
+		event.UserName, event.Body, event.Created = userName.String, body.String, created.Time
+		ans = append(ans, event)
+	}
+	return ans, nil
+}
@@ -0,0 +1,314 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidGroupOperation is returned in case a group operation
+// cannot be performed due to the current state of involved groups
+var ErrInvalidGroupOperation = errors.New("invalid group operation")
+
+type groupState struct {
+	ID              int
+	SourceID        general.SourceID
+	Resolved        bool
+	Escalated       bool
+	EscalationLevel int
+}
+
+func (rdb *ReportsDatabase) selectGroupState(tx *sql.Tx, groupID int) (*groupState, error) {
+	sql1 := "SELECT id, app, instance, tag, resolved_by_user_id, escalated, escalation_level FROM conomi_report_group WHERE id = ?"
+	log.Debug().Str("sql", sql1).Msgf("going to SELECT conomi_report_group WHERE id = %d", groupID)
+	ans := &groupState{}
+	var instance, tag sql.NullString
+	var resolvedBy sql.NullInt32
+	err := tx.QueryRow(rdb.dialect.rebind(sql1), groupID).Scan(
+		&ans.ID, &ans.SourceID.App, &instance, &tag, &resolvedBy, &ans.Escalated, &ans.EscalationLevel)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: group %d not found", ErrInvalidGroupOperation, groupID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ans.SourceID.Instance, ans.SourceID.Tag = instance.String, tag.String
+	ans.Resolved = resolvedBy.Valid
+	return ans, nil
+}
+
+// moveReports moves all the reports (and timeline events) of the `from`
+// groups to the `target` group and removes the `from` groups afterwards
+func (rdb *ReportsDatabase) moveReports(tx *sql.Tx, target *groupState, from []*groupState, userID int) error {
+	if len(from) == 0 {
+		return nil
+	}
+	ids := make([]any, len(from))
+	labels := make([]string, len(from))
+	escalated, level := target.Escalated, target.EscalationLevel
+	for i, group := range from {
+		ids[i] = group.ID
+		labels[i] = fmt.Sprintf("#%d", group.ID)
+		escalated = escalated || group.Escalated
+		level = max(level, group.EscalationLevel)
+	}
+	for _, table := range []string{"conomi_report", "conomi_group_event"} {
+		sql1 := "UPDATE " + table + " SET report_group_id = ? WHERE report_group_id IN (" + mkPlaceholders(len(ids)) + ")"
+		log.Debug().Str("sql", sql1).Msgf("going to move %s rows to group %d", table, target.ID)
+		if _, err := tx.Exec(rdb.dialect.rebind(sql1), append([]any{target.ID}, ids...)...); err != nil {
+			return err
+		}
+	}
+	sql1 := "DELETE FROM conomi_report_group WHERE id IN (" + mkPlaceholders(len(ids)) + ")"
+	log.Debug().Str("sql", sql1).Msg("going to delete merged groups")
+	if _, err := tx.Exec(rdb.dialect.rebind(sql1), ids...); err != nil {
+		return err
+	}
+	if escalated != target.Escalated || level != target.EscalationLevel {
+		sql1 = "UPDATE conomi_report_group SET escalated = ?, escalation_level = ?, escalated_at = COALESCE(escalated_at, ?) WHERE id = ?"
+		if _, err := tx.Exec(rdb.dialect.rebind(sql1), escalated, level, time.Now(), target.ID); err != nil {
+			return err
+		}
+		target.Escalated, target.EscalationLevel = escalated, level
+	}
+	return rdb.recordGroupEvent(tx, &general.GroupEvent{
+		GroupID: target.ID,
+		Type:    general.GroupEventMerged,
+		UserID:  userID,
+		Body:    "Merged group(s) " + strings.Join(labels, ", "),
+	})
+}
+
+// ReopenGroup marks a resolved group as unresolved. As there can be
+// only one unresolved group per source, reports of a possibly existing
+// unresolved group of the same source are merged into the reopened one.
+func (rdb *ReportsDatabase) ReopenGroup(groupID int, userID int) error {
+	err := rdb.withTx(func(tx *sql.Tx) error {
+		group, err := rdb.selectGroupState(tx, groupID)
+		if err != nil {
+			return err
+		}
+		if !group.Resolved {
+			return fmt.Errorf("%w: group %d is not resolved", ErrInvalidGroupOperation, groupID)
+		}
+		whereClause, whereValues := sourceCond(group.SourceID)
+		whereClause = append(whereClause, "resolved_by_user_id IS NULL")
+		sql1 := "SELECT id FROM conomi_report_group WHERE " + strings.Join(whereClause, " AND ")
+		var openGroupID int
+		err = tx.QueryRow(rdb.dialect.rebind(sql1), whereValues...).Scan(&openGroupID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil {
+			openGroup, err := rdb.selectGroupState(tx, openGroupID)
+			if err != nil {
+				return err
+			}
+			if err := rdb.moveReports(tx, group, []*groupState{openGroup}, userID); err != nil {
+				return err
+			}
+		}
+		sql1 = "UPDATE conomi_report_group " +
+			"SET resolved_by_user_id = NULL, acknowledged_by_user_id = NULL, acknowledged = NULL, snoozed_until = NULL " +
+			"WHERE id = ?"
+		log.Debug().Str("sql", sql1).Msgf("going to reopen group WHERE id = %d", groupID)
+		if _, err := tx.Exec(rdb.dialect.rebind(sql1), groupID); err != nil {
+			return err
+		}
+		return rdb.recordGroupEvent(tx, &general.GroupEvent{
+			GroupID: groupID,
+			Type:    general.GroupEventReopened,
+			UserID:  userID,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reopen group: %w", err)
+	}
+	return nil
+}
+
+// AcknowledgeGroup marks an unresolved group as being handled by the user `userID`.
+// The group stays open so it still collects new reports.
+func (rdb *ReportsDatabase) AcknowledgeGroup(groupID int, userID int) error {
+	updated, err := rdb.updateOpenGroup(
+		groupID,
+		"acknowledged_by_user_id = ?, acknowledged = ?",
+		&general.GroupEvent{Type: general.GroupEventAcknowledged, UserID: userID},
+		userID, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge group: %w", err)
+	}
+	if !updated {
+		return fmt.Errorf(
+			"failed to acknowledge group: %w: group %d not found or already resolved",
+			ErrInvalidGroupOperation, groupID)
+	}
+	return nil
+}
+
+// AssignGroup makes the user `assigneeID` an owner of an unresolved group.
+// A negative `assigneeID` removes the current assignment. The `userID`
+// is the user performing the change.
+func (rdb *ReportsDatabase) AssignGroup(groupID int, assigneeID int, userID int) error {
+	var assignee any
+	if assigneeID >= 0 {
+		assignee = assigneeID
+	}
+	err := rdb.withTx(func(tx *sql.Tx) error {
+		group, err := rdb.selectGroupState(tx, groupID)
+		if err != nil {
+			return err
+		}
+		if group.Resolved {
+			return fmt.Errorf("%w: group %d is already resolved", ErrInvalidGroupOperation, groupID)
+		}
+		sql1 := "UPDATE conomi_report_group SET assigned_to_user_id = ? WHERE id = ?"
+		log.Debug().Str("sql", sql1).Msgf("going to assign group WHERE id = %d", groupID)
+		if _, err := tx.Exec(rdb.dialect.rebind(sql1), assignee, groupID); err != nil {
+			return err
+		}
+		event := &general.GroupEvent{GroupID: groupID, Type: general.GroupEventAssigned, UserID: userID}
+		if assigneeID < 0 {
+			event.Body = "Assignment removed"
+
+		} else if assigneeID != userID {
+			sql1 = "SELECT us.user FROM " + rdb.dialect.userTable() + " AS us WHERE us.id = ?"
+			var assigneeName string
+			if err := tx.QueryRow(rdb.dialect.rebind(sql1), assigneeID).Scan(&assigneeName); err != nil {
+				return fmt.Errorf("failed to find assignee %d: %w", assigneeID, err)
+			}
+			event.Body = "Assigned to " + assigneeName
+		}
+		return rdb.recordGroupEvent(tx, event)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to assign group: %w", err)
+	}
+	return nil
+}
+
+// MergeGroups moves reports of groups `groupIDs` into the group `targetID`.
+// All the groups must belong to the same source. Unresolved groups cannot
+// be merged into a resolved one (the target has to be reopened first).
+func (rdb *ReportsDatabase) MergeGroups(targetID int, groupIDs []int, userID int) error {
+	err := rdb.withTx(func(tx *sql.Tx) error {
+		target, err := rdb.selectGroupState(tx, targetID)
+		if err != nil {
+			return err
+		}
+		from := make([]*groupState, 0, len(groupIDs))
+		for _, groupID := range groupIDs {
+			if groupID == targetID {
+				return fmt.Errorf("%w: cannot merge group %d into itself", ErrInvalidGroupOperation, groupID)
+			}
+			group, err := rdb.selectGroupState(tx, groupID)
+			if err != nil {
+				return err
+			}
+			if group.SourceID != target.SourceID {
+				return fmt.Errorf("%w: group %d belongs to a different source", ErrInvalidGroupOperation, groupID)
+			}
+			if target.Resolved && !group.Resolved {
+				return fmt.Errorf(
+					"%w: unresolved group %d cannot be merged into resolved group %d",
+					ErrInvalidGroupOperation, groupID, targetID)
+			}
+			from = append(from, group)
+		}
+		return rdb.moveReports(tx, target, from, userID)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to merge groups: %w", err)
+	}
+	return nil
+}
+
+// SplitGroup moves selected reports of a group into a new group
+// of the same source. As the source may already have an unresolved
+// group, the new group is created as resolved by the `userID`.
+// At least one report must remain in the original group.
+func (rdb *ReportsDatabase) SplitGroup(groupID int, reportIDs []int, userID int) (int, error) {
+	var newGroupID int64
+	err := rdb.withTx(func(tx *sql.Tx) error {
+		group, err := rdb.selectGroupState(tx, groupID)
+		if err != nil {
+			return err
+		}
+		if len(reportIDs) == 0 {
+			return fmt.Errorf("%w: no reports to split", ErrInvalidGroupOperation)
+		}
+		args := make([]any, 0, len(reportIDs)+1)
+		args = append(args, groupID)
+		for _, id := range reportIDs {
+			args = append(args, id)
+		}
+		sql1 := "SELECT COUNT(*) FROM conomi_report WHERE report_group_id = ? AND id IN (" + mkPlaceholders(len(reportIDs)) + ")"
+		var numFound int
+		if err := tx.QueryRow(rdb.dialect.rebind(sql1), args...).Scan(&numFound); err != nil {
+			return err
+		}
+		if numFound != len(reportIDs) {
+			return fmt.Errorf("%w: some of the reports do not belong to group %d", ErrInvalidGroupOperation, groupID)
+		}
+		sql1 = "SELECT COUNT(*) FROM conomi_report WHERE report_group_id = ?"
+		var numReports int
+		if err := tx.QueryRow(rdb.dialect.rebind(sql1), groupID).Scan(&numReports); err != nil {
+			return err
+		}
+		if numFound == numReports {
+			return fmt.Errorf("%w: cannot move all the reports of group %d", ErrInvalidGroupOperation, groupID)
+		}
+		sql1 = "INSERT INTO conomi_report_group (app, instance, tag, created, escalated, escalation_level, escalated_at, resolved_by_user_id) " +
+			"SELECT app, instance, tag, created, escalated, escalation_level, escalated_at, ? FROM conomi_report_group WHERE id = ?"
+		log.Debug().Str("sql", sql1).Msgf("going to split group WHERE id = %d", groupID)
+		newGroupID, err = rdb.dialect.insert(tx, sql1, userID, group.ID)
+		if err != nil {
+			return err
+		}
+		sql1 = "UPDATE conomi_report SET report_group_id = ? WHERE report_group_id = ? AND id IN (" + mkPlaceholders(len(reportIDs)) + ")"
+		if _, err := tx.Exec(rdb.dialect.rebind(sql1), append([]any{newGroupID}, args...)...); err != nil {
+			return err
+		}
+		err = rdb.recordGroupEvent(tx, &general.GroupEvent{
+			GroupID: groupID,
+			Type:    general.GroupEventSplit,
+			UserID:  userID,
+			Body:    fmt.Sprintf("%d report(s) moved to group #%d", len(reportIDs), newGroupID),
+		})
+		if err != nil {
+			return err
+		}
+		return rdb.recordGroupEvent(tx, &general.GroupEvent{
+			GroupID: int(newGroupID),
+			Type:    general.GroupEventCreated,
+			UserID:  userID,
+			Body:    fmt.Sprintf("Split from group #%d", groupID),
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to split group: %w", err)
+	}
+	return int(newGroupID), nil
+}
@@ -0,0 +1,198 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+func TestReopenGroup(t *testing.T) {
+	rdb, userID := newTestStore(t)
+	source := general.SourceID{App: "app1"}
+	resolved := insertTestReports(t, rdb, source, 0, general.SeverityLevelWarning)[0].GroupID
+	if err := rdb.ResolveGroup(resolved, userID); err != nil {
+		t.Fatal(err)
+	}
+	open := insertTestReports(t, rdb, source, 1, general.SeverityLevelCritical)[0].GroupID
+	if err := rdb.EscalateGroup(open); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rdb.ReopenGroup(open, userID); !errors.Is(err, ErrInvalidGroupOperation) {
+		t.Errorf("reopening an open group: expected ErrInvalidGroupOperation, got %v", err)
+	}
+	if err := rdb.ReopenGroup(resolved, userID); err != nil {
+		t.Fatal(err)
+	}
+	// the open group of the same source is merged into the reopened one
+	group, err := rdb.SelectGroup(resolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.ResolvedByUserID != -1 || group.ReportCount != 2 || !group.Escalated {
+		t.Errorf("unexpected reopened group %+v", group)
+	}
+	if _, err := rdb.SelectGroup(open); err == nil {
+		t.Errorf("expected group %d to be merged", open)
+	}
+	// new reports go to the reopened group
+	if report := insertTestReports(t, rdb, source, 2, general.SeverityLevelInfo)[0]; report.GroupID != resolved {
+		t.Errorf("expected a new report in group %d, got %d", resolved, report.GroupID)
+	}
+}
+
+func TestMergeGroups(t *testing.T) {
+	rdb, userID := newTestStore(t)
+	source := general.SourceID{App: "app1"}
+	var resolved []int
+	for i := 0; i < 2; i++ {
+		groupID := insertTestReports(t, rdb, source, i, general.SeverityLevelWarning)[0].GroupID
+		if err := rdb.ResolveGroup(groupID, userID); err != nil {
+			t.Fatal(err)
+		}
+		resolved = append(resolved, groupID)
+	}
+	target := insertTestReports(t, rdb, source, 2, general.SeverityLevelInfo)[0].GroupID
+	other := insertTestReports(t, rdb, general.SourceID{App: "app2"}, 3, general.SeverityLevelInfo)[0].GroupID
+
+	tests := []struct {
+		name     string
+		target   int
+		groupIDs []int
+	}{
+		{"into itself", target, []int{target}},
+		{"different source", target, []int{other}},
+		{"missing group", target, []int{other + 100}},
+		{"missing target", other + 100, []int{resolved[0]}},
+		{"open into resolved", resolved[0], []int{target}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := rdb.MergeGroups(tt.target, tt.groupIDs, userID); !errors.Is(err, ErrInvalidGroupOperation) {
+				t.Errorf("expected ErrInvalidGroupOperation, got %v", err)
+			}
+		})
+	}
+
+	if err := rdb.MergeGroups(target, resolved, userID); err != nil {
+		t.Fatal(err)
+	}
+	group, err := rdb.SelectGroup(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.ReportCount != 3 || group.ResolvedByUserID != -1 {
+		t.Errorf("unexpected merged group %+v", group)
+	}
+	for _, groupID := range resolved {
+		if _, err := rdb.SelectGroup(groupID); err == nil {
+			t.Errorf("expected group %d to be removed", groupID)
+		}
+	}
+}
+
+func TestSplitGroup(t *testing.T) {
+	rdb, userID := newTestStore(t)
+	source := general.SourceID{App: "app1"}
+	reports := insertTestReports(
+		t, rdb, source, 0,
+		general.SeverityLevelInfo, general.SeverityLevelWarning, general.SeverityLevelCritical,
+	)
+	groupID := reports[0].GroupID
+	if err := rdb.EscalateGroup(groupID); err != nil {
+		t.Fatal(err)
+	}
+	other := insertTestReports(t, rdb, general.SourceID{App: "app2"}, 1, general.SeverityLevelInfo)[0]
+
+	tests := []struct {
+		name      string
+		groupID   int
+		reportIDs []int
+	}{
+		{"no reports", groupID, []int{}},
+		{"foreign report", groupID, []int{reports[0].ID, other.ID}},
+		{"missing report", groupID, []int{reports[0].ID + 100}},
+		{"missing group", groupID + 100, []int{reports[0].ID}},
+		{"all reports", groupID, []int{reports[0].ID, reports[1].ID, reports[2].ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := rdb.SplitGroup(tt.groupID, tt.reportIDs, userID); !errors.Is(err, ErrInvalidGroupOperation) {
+				t.Errorf("expected ErrInvalidGroupOperation, got %v", err)
+			}
+		})
+	}
+
+	newGroupID, err := rdb.SplitGroup(groupID, []int{reports[1].ID, reports[2].ID}, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := rdb.SelectGroup(groupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.ReportCount != 1 || group.ResolvedByUserID != -1 {
+		t.Errorf("unexpected original group %+v", group)
+	}
+	// the new group is resolved as the source already has an open group
+	newGroup, err := rdb.SelectGroup(newGroupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newGroup.ReportCount != 2 || newGroup.ResolvedByUserID != userID || !newGroup.Escalated {
+		t.Errorf("unexpected new group %+v", newGroup)
+	}
+	if newGroup.SourceID != source {
+		t.Errorf("expected source %v, got %v", source, newGroup.SourceID)
+	}
+}
+
+func TestAssignGroupEvents(t *testing.T) {
+	rdb, userID := newTestStore(t)
+	otherID, err := rdb.dialect.insert(rdb.db, "INSERT INTO "+rdb.dialect.userTable()+" (user) VALUES (?)", "colleague")
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupID := insertTestReports(t, rdb, general.SourceID{App: "app1"}, 0, general.SeverityLevelWarning)[0].GroupID
+	for _, assigneeID := range []int{userID, int(otherID), -1} {
+		if err := rdb.AssignGroup(groupID, assigneeID, userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	events, err := rdb.ListGroupEvents(groupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, event := range events {
+		if event.Type != general.GroupEventAssigned {
+			continue
+		}
+		if event.UserID != userID {
+			t.Errorf("expected the event recorded for the acting user %d, got %d", userID, event.UserID)
+		}
+		got = append(got, event.Body)
+	}
+	want := []string{"", "Assigned to colleague", "Assignment removed"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected events %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/rs/zerolog/log"
+)
+
+// ListGroupsArgs specifies which report groups should be listed
+type ListGroupsArgs struct {
+	SourceID general.SourceID
+
+	// Resolved allows also resolved groups to be listed
+	Resolved bool
+
+	// Escalated filters only escalated groups
+	Escalated bool
+
+	// AssignedTo filters groups assigned to a user with the ID
+	// (zero value means no filtering)
+	AssignedTo int
+
+	Limit  int
+	Offset int
+}
+
+func (args *ListGroupsArgs) filter() ([]string, []any) {
+	whereParts := make([]string, 0, 5)
+	whereValues := make([]any, 0, 4)
+	if !args.Resolved {
+		whereParts = append(whereParts, "crg.resolved_by_user_id IS NULL")
+	}
+	if args.SourceID.App != "" {
+		whereParts = append(whereParts, "crg.app = ?")
+		whereValues = append(whereValues, args.SourceID.App)
+	}
+	if args.SourceID.Instance != "" {
+		whereParts = append(whereParts, "crg.instance = ?")
+		whereValues = append(whereValues, args.SourceID.Instance)
+	}
+	if args.SourceID.Tag != "" {
+		whereParts = append(whereParts, "crg.tag = ?")
+		whereValues = append(whereValues, args.SourceID.Tag)
+	}
+	if args.Escalated {
+		whereParts = append(whereParts, "crg.escalated = ?")
+		whereValues = append(whereValues, true)
+	}
+	if args.AssignedTo > 0 {
+		whereParts = append(whereParts, "crg.assigned_to_user_id = ?")
+		whereValues = append(whereValues, args.AssignedTo)
+	}
+	return whereParts, whereValues
+}
+
+// groupSeverities specifies the severities (and their order)
+// in the group histogram columns
+var groupSeverities = []general.SeverityLevel{
+	general.SeverityLevelInfo,
+	general.SeverityLevelWarning,
+	general.SeverityLevelCritical,
+	general.SeverityLevelRecovery,
+}
+
+func (rdb *ReportsDatabase) selectGroups(whereClause string, tailClause string, whereValues ...any) ([]*general.ReportGroup, error) {
+	sql1 := "SELECT crg.id, crg.app, crg.instance, crg.tag, crg.created, crg.escalated, crg.resolved_by_user_id, us.user, " +
+		"crg.acknowledged_by_user_id, ack.user, crg.acknowledged, crg.assigned_to_user_id, asg.user, crg.snoozed_until, " +
+		"COUNT(cr.id), COALESCE(MIN(cr.created), crg.created), COALESCE(MAX(cr.created), crg.created) AS last_seen"
+	values := make([]any, 0, len(groupSeverities)+len(whereValues))
+	for _, severity := range groupSeverities {
+		sql1 += ", SUM(CASE WHEN cr.severity = ? THEN 1 ELSE 0 END)"
+		values = append(values, severity)
+	}
+	values = append(values, whereValues...)
+	sql1 += " FROM conomi_report_group AS crg " +
+		"LEFT JOIN conomi_report AS cr ON crg.id = cr.report_group_id " +
+		"LEFT JOIN " + rdb.dialect.userTable() + " AS us ON crg.resolved_by_user_id = us.id " +
+		"LEFT JOIN " + rdb.dialect.userTable() + " AS ack ON crg.acknowledged_by_user_id = ack.id " +
+		"LEFT JOIN " + rdb.dialect.userTable() + " AS asg ON crg.assigned_to_user_id = asg.id " +
+		whereClause +
+		"GROUP BY crg.id, crg.app, crg.instance, crg.tag, crg.created, crg.escalated, crg.resolved_by_user_id, us.user, " +
+		"crg.acknowledged_by_user_id, ack.user, crg.acknowledged, crg.assigned_to_user_id, asg.user, crg.snoozed_until " +
+		tailClause
+	log.Debug().Str("sql", sql1).Msg("going to SELECT conomi_report_group")
+	rows, err := rdb.query(sql1, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ans := make([]*general.ReportGroup, 0, 100)
+	for rows.Next() {
+		group := &general.ReportGroup{Severities: make(map[general.SeverityLevel]int)}
+		var instance, tag, resolvedByUserName, acknowledgedByUserName, assignedToUserName sql.NullString
+		var resolvedByUserID, acknowledgedByUserID, assignedToUserID sql.NullInt32
+		var acknowledged, snoozedUntil, firstSeen, lastSeen sqlTime
+		counts := make([]int, len(groupSeverities))
+		dest := []any{
+			&group.ID, &group.SourceID.App, &instance, &tag, &group.Created, &group.Escalated,
+			&resolvedByUserID, &resolvedByUserName, &acknowledgedByUserID, &acknowledgedByUserName, &acknowledged,
+			&assignedToUserID, &assignedToUserName, &snoozedUntil,
+			&group.ReportCount, &firstSeen, &lastSeen,
+		}
+		for i := range counts {
+			dest = append(dest, &counts[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		group.SourceID.Instance, group.SourceID.Tag = instance.String, tag.String
+		group.ResolvedByUserID = -1
+		if resolvedByUserID.Valid {
+			group.ResolvedByUserID = int(resolvedByUserID.Int32)
+		}
+		group.ResolvedByUserName = resolvedByUserName.String
+		group.AcknowledgedByUserID = -1
+		if acknowledgedByUserID.Valid {
+			group.AcknowledgedByUserID = int(acknowledgedByUserID.Int32)
+			group.Acknowledged = &acknowledged.Time
+		}
+		group.AcknowledgedByUserName = acknowledgedByUserName.String
+		group.AssignedToUserID = -1
+		if assignedToUserID.Valid {
+			group.AssignedToUserID = int(assignedToUserID.Int32)
+		}
+		group.AssignedToUserName = assignedToUserName.String
+		if !snoozedUntil.Time.IsZero() {
+			group.SnoozedUntil = &snoozedUntil.Time
+		}
+		group.FirstSeen, group.LastSeen = firstSeen.Time, lastSeen.Time
+		for i, severity := range groupSeverities {
+			group.Severities[severity] = counts[i]
+		}
+		ans = append(ans, group)
+	}
+	return ans, nil
+}
+
+func (rdb *ReportsDatabase) ListGroups(args ListGroupsArgs) ([]*general.ReportGroup, error) {
+	whereParts, whereValues := args.filter()
+	tailClause := "ORDER BY last_seen DESC, crg.id DESC"
+	if args.Limit > 0 {
+		tailClause += " LIMIT ? OFFSET ?"
+		whereValues = append(whereValues, args.Limit, args.Offset)
+	}
+	groups, err := rdb.selectGroups(mkWhereClause(whereParts), tailClause, whereValues...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	return groups, nil
+}
+
+func (rdb *ReportsDatabase) CountGroups(args ListGroupsArgs) (int, error) {
+	whereParts, whereValues := args.filter()
+	sql1 := "SELECT COUNT(*) FROM conomi_report_group AS crg " + mkWhereClause(whereParts)
+	log.Debug().Str("sql", sql1).Msg("going to count conomi_report_group")
+	var ans int
+	if err := rdb.queryRow(sql1, whereValues...).Scan(&ans); err != nil {
+		return 0, fmt.Errorf("failed to count groups: %w", err)
+	}
+	return ans, nil
+}
+
+// SelectGroup provides a group with its statistics. In case
+// the group does not exist, sql.ErrNoRows is returned.
+func (rdb *ReportsDatabase) SelectGroup(groupID int) (*general.ReportGroup, error) {
+	groups, err := rdb.selectGroups("WHERE crg.id = ? ", "", groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select group: %w", err)
+	}
+	if len(groups) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return groups[0], nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+// newTestStore creates a store backed by a new SQLite database
+// along with a user able to act on groups
+func newTestStore(t *testing.T) (*ReportsDatabase, int) {
+	t.Helper()
+	db, dialect := openTestDB(t)
+	rdb := NewReportsDatabase(db, dialect)
+	userID, err := dialect.insert(db, "INSERT INTO "+dialect.userTable()+" (user) VALUES (?)", "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rdb, int(userID)
+}
+
+var testCreated = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// insertTestReports inserts reports of the source (one per severity,
+// a minute apart starting `hours` after testCreated) and returns them
+func insertTestReports(
+	t *testing.T,
+	rdb *ReportsDatabase,
+	sourceID general.SourceID,
+	hours int,
+	severities ...general.SeverityLevel,
+) []*general.Report {
+	t.Helper()
+	ans := make([]*general.Report, len(severities))
+	for i, severity := range severities {
+		ans[i] = &general.Report{
+			SourceID: sourceID,
+			Severity: severity,
+			Subject:  string(severity) + " report",
+			Body:     "body",
+			Created:  testCreated.Add(time.Duration(hours)*time.Hour + time.Duration(i)*time.Minute),
+		}
+		if err := rdb.InsertReport(ans[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ans
+}
+
+func groupIDs(groups []*general.ReportGroup) []int {
+	ans := make([]int, len(groups))
+	for i, group := range groups {
+		ans[i] = group.ID
+	}
+	return ans
+}
+
+func TestListGroups(t *testing.T) {
+	rdb, userID := newTestStore(t)
+	app1 := general.SourceID{App: "app1"}
+	app2 := general.SourceID{App: "app2", Instance: "srv1"}
+	resolved := insertTestReports(t, rdb, app1, 0, general.SeverityLevelWarning)[0].GroupID
+	if err := rdb.ResolveGroup(resolved, userID); err != nil {
+		t.Fatal(err)
+	}
+	open1 := insertTestReports(t, rdb, app1, 1, general.SeverityLevelInfo, general.SeverityLevelCritical)[0].GroupID
+	open2 := insertTestReports(t, rdb, app2, 2, general.SeverityLevelWarning)[0].GroupID
+	if err := rdb.EscalateGroup(open2); err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.AssignGroup(open1, userID, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args ListGroupsArgs
+		want []int
+	}{
+		{"open", ListGroupsArgs{}, []int{open2, open1}},
+		{"with resolved", ListGroupsArgs{Resolved: true}, []int{open2, open1, resolved}},
+		{"by app", ListGroupsArgs{SourceID: app1, Resolved: true}, []int{open1, resolved}},
+		{"by instance", ListGroupsArgs{SourceID: general.SourceID{Instance: "srv1"}}, []int{open2}},
+		{"escalated", ListGroupsArgs{Escalated: true}, []int{open2}},
+		{"assigned", ListGroupsArgs{AssignedTo: userID}, []int{open1}},
+		{"paging", ListGroupsArgs{Resolved: true, Limit: 1, Offset: 1}, []int{open1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups, err := rdb.ListGroups(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := groupIDs(groups); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListGroups() = %v, want %v", got, tt.want)
+			}
+			count, err := rdb.CountGroups(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.args.Limit == 0 && count != len(tt.want) {
+				t.Errorf("CountGroups() = %d, want %d", count, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestSelectGroup(t *testing.T) {
+	rdb, _ := newTestStore(t)
+	reports := insertTestReports(
+		t, rdb, general.SourceID{App: "app1", Tag: "db"}, 0,
+		general.SeverityLevelInfo, general.SeverityLevelWarning, general.SeverityLevelWarning,
+	)
+	group, err := rdb.SelectGroup(reports[0].GroupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.ReportCount != 3 {
+		t.Errorf("expected 3 reports, got %d", group.ReportCount)
+	}
+	if group.Severities[general.SeverityLevelWarning] != 2 || group.Severities[general.SeverityLevelInfo] != 1 {
+		t.Errorf("unexpected severities %v", group.Severities)
+	}
+	if !group.FirstSeen.Equal(reports[0].Created) || !group.LastSeen.Equal(reports[2].Created) {
+		t.Errorf("unexpected first/last seen %v, %v", group.FirstSeen, group.LastSeen)
+	}
+	if group.SourceID.Tag != "db" || group.ResolvedByUserID != -1 || group.AssignedToUserID != -1 {
+		t.Errorf("unexpected group %+v", group)
+	}
+	if _, err := rdb.SelectGroup(reports[0].GroupID + 100); err == nil {
+		t.Error("expected an error for a missing group")
+	}
+}
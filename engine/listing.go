@@ -0,0 +1,214 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+const (
+	SortOrderDesc = "desc"
+	SortOrderAsc  = "asc"
+)
+
+// ReportsCursor identifies a position in a list of reports
+// sorted by creation time (report ID is used as a tie-breaker)
+type ReportsCursor struct {
+	Created time.Time
+	ID      int
+}
+
+func (c *ReportsCursor) Encode() string {
+	raw := c.Created.Format(time.RFC3339Nano) + "," + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeReportsCursor(value string) (*ReportsCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	rawCreated, rawID, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid cursor `%s`", value)
+	}
+	created, err := time.Parse(time.RFC3339Nano, rawCreated)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	id, err := strconv.Atoi(rawID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	return &ReportsCursor{Created: created, ID: id}, nil
+}
+
+// ListReportsArgs specifies which reports (and in which order)
+// should be listed
+type ListReportsArgs struct {
+	SourceID general.SourceID
+
+	// Resolved allows also reports of resolved groups
+	// to be listed
+	Resolved bool
+
+	// Severities limits listed severities (empty means any)
+	Severities []general.SeverityLevel
+
+	// From and To limit the creation time of reports
+	// (zero values are ignored)
+	From time.Time
+	To   time.Time
+
+	// ToExclusive excludes reports created exactly at To
+	// (e.g. when To is the midnight following a whole day)
+	ToExclusive bool
+
+	// Escalated filters only reports of escalated groups
+	Escalated bool
+
+	// ResolvedBy filters reports of groups resolved by a specified
+	// user (name). Resolved groups are listed regardless of the
+	// Resolved field in such case.
+	ResolvedBy string
+
+	GroupID int
+
+	// Subject filters reports with subject containing the value
+	// (case insensitive)
+	Subject string
+
+	// Search applies a full-text query (nil means no search)
+	Search *SearchQuery
+
+	// Order is either `desc` (default) or `asc`
+	Order string
+
+	// Cursor specifies the last report of the previous page
+	// (nil for the first page)
+	Cursor *ReportsCursor
+
+	// Limit specifies max. number of returned reports
+	// (zero means no limit)
+	Limit int
+}
+
+func (args *ListReportsArgs) Validate() error {
+	for _, severity := range args.Severities {
+		if err := severity.Validate(); err != nil {
+			return err
+		}
+	}
+	if !args.From.IsZero() && !args.To.IsZero() && args.From.After(args.To) {
+		return fmt.Errorf("`from` must not be after `to`")
+	}
+	if args.Order != "" && args.Order != SortOrderDesc && args.Order != SortOrderAsc {
+		return fmt.Errorf("invalid order `%s`, use `%s` or `%s`", args.Order, SortOrderDesc, SortOrderAsc)
+	}
+	if args.Limit < 0 {
+		return fmt.Errorf("limit cannot be negative")
+	}
+	return nil
+}
+
+func (args *ListReportsArgs) isAscending() bool {
+	return args.Order == SortOrderAsc
+}
+
+// filter provides WHERE conditions and respective values
+// for the listing (without cursor related conditions)
+func (args *ListReportsArgs) filter(dialect sqlDialect) ([]string, []any) {
+	whereParts := make([]string, 0, 10)
+	whereValues := make([]any, 0, 10)
+	if args.ResolvedBy != "" {
+		whereParts = append(
+			whereParts,
+			"crg.resolved_by_user_id IN (SELECT us2.id FROM "+dialect.userTable()+" AS us2 WHERE us2.user = ?)",
+		)
+		whereValues = append(whereValues, args.ResolvedBy)
+
+	} else if !args.Resolved {
+		whereParts = append(whereParts, "crg.resolved_by_user_id IS NULL")
+	}
+	if args.SourceID.App != "" {
+		whereParts = append(whereParts, "crg.app = ?")
+		whereValues = append(whereValues, args.SourceID.App)
+	}
+	if args.SourceID.Instance != "" {
+		whereParts = append(whereParts, "crg.instance = ?")
+		whereValues = append(whereValues, args.SourceID.Instance)
+	}
+	if args.SourceID.Tag != "" {
+		whereParts = append(whereParts, "crg.tag = ?")
+		whereValues = append(whereValues, args.SourceID.Tag)
+	}
+	if len(args.Severities) > 0 {
+		whereParts = append(whereParts, "cr.severity IN ("+mkPlaceholders(len(args.Severities))+")")
+		for _, severity := range args.Severities {
+			whereValues = append(whereValues, severity)
+		}
+	}
+	if !args.From.IsZero() {
+		whereParts = append(whereParts, dialect.timeCmp("cr.created", ">="))
+		whereValues = append(whereValues, args.From)
+	}
+	if !args.To.IsZero() {
+		if args.ToExclusive {
+			whereParts = append(whereParts, dialect.timeCmp("cr.created", "<"))
+
+		} else {
+			whereParts = append(whereParts, dialect.timeCmp("cr.created", "<="))
+		}
+		whereValues = append(whereValues, args.To)
+	}
+	if args.Escalated {
+		whereParts = append(whereParts, "crg.escalated = ?")
+		whereValues = append(whereValues, true)
+	}
+	if args.GroupID > 0 {
+		whereParts = append(whereParts, "crg.id = ?")
+		whereValues = append(whereValues, args.GroupID)
+	}
+	if args.Subject != "" {
+		whereParts = append(whereParts, "LOWER(cr.subject) LIKE ? ESCAPE '!'")
+		whereValues = append(whereValues, "%"+escapeLike(strings.ToLower(args.Subject))+"%")
+	}
+	if args.Search != nil {
+		searchParts, searchValues := args.Search.conditions(dialect)
+		whereParts = append(whereParts, searchParts...)
+		whereValues = append(whereValues, searchValues...)
+	}
+	return whereParts, whereValues
+}
+
+// escapeLike escapes LIKE wildcards using the `!` escape character
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
+
+func mkWhereClause(whereParts []string) string {
+	if len(whereParts) > 0 {
+		return "WHERE " + strings.Join(whereParts, " AND ") + " "
+	}
+	return ""
+}
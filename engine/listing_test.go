@@ -0,0 +1,114 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+func TestReportsCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor ReportsCursor
+	}{
+		{"utc", ReportsCursor{Created: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), ID: 42}},
+		{"nanoseconds", ReportsCursor{Created: time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC), ID: 1}},
+		{"zone offset", ReportsCursor{Created: time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600)), ID: 7}},
+		{"zero id", ReportsCursor{Created: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), ID: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := tt.cursor.Encode()
+			decoded, err := DecodeReportsCursor(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !decoded.Created.Equal(tt.cursor.Created) || decoded.ID != tt.cursor.ID {
+				t.Errorf("DecodeReportsCursor(%q) = %+v, want %+v", encoded, decoded, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeReportsCursorInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte("2024-03-01T12:30:00Z,1"))},
+		{"missing id", encode("2024-03-01T12:30:00Z")},
+		{"invalid time", encode("yesterday,1")},
+		{"invalid id", encode("2024-03-01T12:30:00Z,abc")},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cursor, err := DecodeReportsCursor(tt.value); err == nil {
+				t.Errorf("DecodeReportsCursor(%q) = %+v, expected an error", tt.value, cursor)
+			}
+		})
+	}
+}
+
+func TestListReportsSubjectFilter(t *testing.T) {
+	rdb, _ := newTestStore(t)
+	for i, subject := range []string{"ŘADIČ SELHAL", "Řadič obnoven", "Disk 100% full"} {
+		err := rdb.InsertReport(&general.Report{
+			SourceID: general.SourceID{App: "test"},
+			Severity: general.SeverityLevelWarning,
+			Subject:  subject,
+			Created:  testCreated.Add(time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		subject string
+		want    []string
+	}{
+		{"řadič", []string{"ŘADIČ SELHAL", "Řadič obnoven"}},
+		{"ŘADIČ OBN", []string{"Řadič obnoven"}},
+		{"selhal", []string{"ŘADIČ SELHAL"}},
+		{"100%", []string{"Disk 100% full"}},
+		{"0%_", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			reports, err := rdb.ListReports(ListReportsArgs{Subject: tt.subject, Order: SortOrderAsc})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(reports))
+			for i, report := range reports {
+				got[i] = report.Subject
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("subject filter %q = %q, want %q", tt.subject, got, tt.want)
+			}
+		})
+	}
+}
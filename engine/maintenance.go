@@ -0,0 +1,118 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/rs/zerolog/log"
+)
+
+func (rdb *ReportsDatabase) InsertMaintenanceWindow(window *general.MaintenanceWindow) error {
+	if window.Created.IsZero() {
+		window.Created = time.Now()
+	}
+	var start, end any
+	if window.Start != nil {
+		start = *window.Start
+	}
+	if window.End != nil {
+		end = *window.End
+	}
+	sql1 := "INSERT INTO conomi_maintenance_window " +
+		"(app, instance, start_time, end_time, cron, duration_mins, description, user_id, created) " +
+		"VALUES (?,?,?,?,?,?,?,?,?)"
+	log.Debug().Str("sql", sql1).Msg("going to INSERT conomi_maintenance_window")
+	windowID, err := rdb.dialect.insert(
+		rdb.db, sql1,
+		window.App, window.Instance, start, end,
+		sql.NullString{String: window.Cron, Valid: window.Cron != ""},
+		sql.NullInt32{Int32: int32(window.DurationMins), Valid: window.Cron != ""},
+		window.Description,
+		sql.NullInt32{Int32: int32(window.UserID), Valid: window.UserID >= 0},
+		window.Created,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert maintenance window: %w", err)
+	}
+	window.ID = int(windowID)
+	return nil
+}
+
+// DeleteMaintenanceWindow removes a window. In case the window
+// does not exist, sql.ErrNoRows is returned.
+func (rdb *ReportsDatabase) DeleteMaintenanceWindow(windowID int) error {
+	sql1 := "DELETE FROM conomi_maintenance_window WHERE id = ?"
+	log.Debug().Str("sql", sql1).Msgf("going to DELETE conomi_maintenance_window WHERE id = %d", windowID)
+	res, err := rdb.exec(sql1, windowID)
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (rdb *ReportsDatabase) ListMaintenanceWindows() ([]*general.MaintenanceWindow, error) {
+	sql1 := "SELECT mw.id, mw.app, mw.instance, mw.start_time, mw.end_time, mw.cron, mw.duration_mins, " +
+		"mw.description, mw.user_id, us.user, mw.created " +
+		"FROM conomi_maintenance_window AS mw " +
+		"LEFT JOIN " + rdb.dialect.userTable() + " AS us ON mw.user_id = us.id " +
+		"ORDER BY mw.app, mw.instance, mw.id"
+	log.Debug().Str("sql", sql1).Msg("going to SELECT conomi_maintenance_window")
+	rows, err := rdb.query(sql1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+	}
+	defer rows.Close()
+	ans := make([]*general.MaintenanceWindow, 0, 10)
+	for rows.Next() {
+		window := &general.MaintenanceWindow{UserID: -1}
+		var cron, description, userName sql.NullString
+		var durationMins, userID sql.NullInt32
+		var start, end, created sqlTime
+		err := rows.Scan(
+			&window.ID, &window.App, &window.Instance, &start, &end, &cron, &durationMins,
+			&description, &userID, &userName, &created,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+		}
+		if !start.Time.IsZero() {
+			window.Start = &start.Time
+		}
+		if !end.Time.IsZero() {
+			window.End = &end.Time
+		}
+		if userID.Valid {
+			window.UserID = int(userID.Int32)
+		}
+		window.Cron, window.DurationMins = cron.String, int(durationMins.Int32)
+		window.Description, window.UserName = description.String, userName.String
+		window.Created = created.Time
+		ans = append(ans, window)
+	}
+	return ans, nil
+}
@@ -0,0 +1,302 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationSteps provides parts of migrations (identified by name)
+// which cannot be expressed in SQL. They run after the up script
+// within the same transaction.
+var migrationSteps = map[string]func(tx *sql.Tx, dialect sqlDialect) error{
+	"0003_fulltext": backfillSearchIndex,
+}
+
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+type MigrationStatus struct {
+	Current int
+	Latest  int
+	Pending []*Migration
+}
+
+// Migrator applies versioned schema migrations embedded
+// in the application. Applied versions are stored
+// in the `conomi_schema_version` table.
+type Migrator struct {
+	db         *sql.DB
+	dialect    sqlDialect
+	migrations []*Migration
+}
+
+// splitStatements splits an SQL script into individual statements
+// (not all the drivers support multiple statements per query)
+func splitStatements(script string) []string {
+	ans := make([]string, 0, 5)
+	for _, stmt := range strings.Split(script, ";\n") {
+		stmt = strings.TrimSuffix(strings.TrimSpace(stmt), ";")
+		var isEmpty = true
+		for _, line := range strings.Split(stmt, "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "--") {
+				isEmpty = false
+				break
+			}
+		}
+		if !isEmpty {
+			ans = append(ans, stmt)
+		}
+	}
+	return ans
+}
+
+func loadMigrations(dir string) ([]*Migration, error) {
+	dir = path.Join("migrations", dir)
+	entries, err := migrationFiles.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		var direction string
+		if strings.HasSuffix(fileName, ".up.sql") {
+			direction = "up"
+
+		} else if strings.HasSuffix(fileName, ".down.sql") {
+			direction = "down"
+
+		} else {
+			continue
+		}
+		name := strings.TrimSuffix(fileName, "."+direction+".sql")
+		rawVersion, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(rawVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load migrations: invalid file name %s", fileName)
+		}
+		data, err := migrationFiles.ReadFile(path.Join(dir, fileName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load migrations: %w", err)
+		}
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: name}
+			byVersion[version] = mig
+		}
+		if direction == "up" {
+			mig.up = string(data)
+
+		} else {
+			mig.down = string(data)
+		}
+	}
+	ans := make([]*Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.up == "" {
+			return nil, fmt.Errorf("failed to load migrations: missing up script for %s", mig.Name)
+		}
+		ans = append(ans, mig)
+	}
+	sort.Slice(ans, func(i, j int) bool { return ans[i].Version < ans[j].Version })
+	return ans, nil
+}
+
+func (m *Migrator) currentVersion() (int, error) {
+	var version sql.NullInt64
+	row := m.db.QueryRow("SELECT MAX(version) FROM conomi_schema_version")
+	if err := row.Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+func (m *Migrator) latestVersion() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// runScript applies a migration script along with updating
+// the schema version table within a single transaction.
+// Please note that MySQL commits each DDL statement implicitly
+// so a failed script may remain partially applied there. To be able
+// to simply run the migration again, statements failing just because
+// their change is already present are skipped for such engines.
+func (m *Migrator) runScript(
+	mig *Migration,
+	script string,
+	step func(tx *sql.Tx, dialect sqlDialect) error,
+	versionQuery string,
+	args ...any,
+) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, stmt := range splitStatements(script) {
+		log.Debug().Str("sql", stmt).Msgf("migration %s", mig.Name)
+		if _, err := tx.Exec(stmt); err != nil {
+			if !m.dialect.transactionalDDL() && m.dialect.isAppliedSchemaChange(err) {
+				log.Warn().Err(err).Str("migration", mig.Name).Msg("skipping already applied statement")
+				continue
+			}
+			tx.Rollback()
+			return err
+		}
+	}
+	if step != nil {
+		if err := step(tx, m.dialect); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.Exec(m.dialect.rebind(versionQuery), args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Status provides current and latest schema versions
+// along with migrations waiting to be applied
+func (m *Migrator) Status() (*MigrationStatus, error) {
+	current, err := m.currentVersion()
+	if err != nil {
+		return nil, err
+	}
+	ans := &MigrationStatus{
+		Current: current,
+		Latest:  m.latestVersion(),
+		Pending: make([]*Migration, 0, len(m.migrations)),
+	}
+	for _, mig := range m.migrations {
+		if mig.Version > current {
+			ans.Pending = append(ans.Pending, mig)
+		}
+	}
+	return ans, nil
+}
+
+// Up applies all the pending migrations and returns them
+func (m *Migrator) Up() ([]*Migration, error) {
+	status, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	for _, mig := range status.Pending {
+		log.Info().Str("migration", mig.Name).Msg("applying schema migration")
+		err := m.runScript(
+			mig,
+			mig.up,
+			migrationSteps[mig.Name],
+			"INSERT INTO conomi_schema_version (version, name, applied) VALUES (?, ?, ?)",
+			mig.Version, mig.Name, time.Now(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply migration %s: %w", mig.Name, err)
+		}
+	}
+	return status.Pending, nil
+}
+
+// Down reverts the last applied migration and returns it.
+// In case there is nothing to revert, nil is returned.
+func (m *Migrator) Down() (*Migration, error) {
+	current, err := m.currentVersion()
+	if err != nil {
+		return nil, err
+	}
+	if current == 0 {
+		return nil, nil
+	}
+	for _, mig := range m.migrations {
+		if mig.Version != current {
+			continue
+		}
+		if mig.down == "" {
+			return nil, fmt.Errorf("migration %s cannot be reverted", mig.Name)
+		}
+		log.Info().Str("migration", mig.Name).Msg("reverting schema migration")
+		err := m.runScript(
+			mig,
+			mig.down,
+			nil,
+			"DELETE FROM conomi_schema_version WHERE version = ?",
+			mig.Version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to revert migration %s: %w", mig.Name, err)
+		}
+		return mig, nil
+	}
+	return nil, fmt.Errorf("unknown schema version %d", current)
+}
+
+func newMigrator(db *sql.DB, dialect sqlDialect) (*Migrator, error) {
+	migrations, err := loadMigrations(dialect.migrationsDir())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(dialect.schemaVersionTable()); err != nil {
+		return nil, fmt.Errorf("failed to create schema version table: %w", err)
+	}
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+	}, nil
+}
+
+// NewMigrator creates a schema migrator for the configured database.
+// The migrator should be closed once it is no longer needed.
+func NewMigrator(conf *DBConf) (*Migrator, error) {
+	db, dialect, err := openDB(conf)
+	if err != nil {
+		return nil, err
+	}
+	ans, err := newMigrator(db, dialect)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return ans, nil
+}
+
+// Close closes the underlying database
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
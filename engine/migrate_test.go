@@ -0,0 +1,154 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// openTestDB creates a new SQLite database with all the migrations applied
+func openTestDB(t *testing.T) (*sql.DB, sqlDialect) {
+	t.Helper()
+	db, err := openSQLite(&DBConf{Name: filepath.Join(t.TempDir(), "conomi.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := newMigrator(db, sqliteDialect{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db, sqliteDialect{}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"empty", "", []string{}},
+		{"single without semicolon", "SELECT 1", []string{"SELECT 1"}},
+		{"single with semicolon", "SELECT 1;\n", []string{"SELECT 1"}},
+		{"multiple", "SELECT 1;\n\nSELECT 2;\nSELECT 3;", []string{"SELECT 1", "SELECT 2", "SELECT 3"}},
+		{"comment only", "-- nothing here\n;\nSELECT 1;", []string{"SELECT 1"}},
+		{
+			"leading comment",
+			"-- a comment\nCREATE TABLE t (\n    a int\n);\n",
+			[]string{"-- a comment\nCREATE TABLE t (\n    a int\n)"},
+		},
+		{"semicolon within line", "SELECT ';' FROM t;\n", []string{"SELECT ';' FROM t"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements(%q) = %q, want %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	var expected []string
+	for _, dialect := range []sqlDialect{mysqlDialect{}, postgresDialect{}, sqliteDialect{}} {
+		t.Run(dialect.migrationsDir(), func(t *testing.T) {
+			migrations, err := loadMigrations(dialect.migrationsDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := make([]string, len(migrations))
+			for i, mig := range migrations {
+				if mig.Version != i+1 {
+					t.Errorf("migration %s has version %d, expected %d", mig.Name, mig.Version, i+1)
+				}
+				if mig.down == "" {
+					t.Errorf("migration %s has no down script", mig.Name)
+				}
+				names[i] = mig.Name
+			}
+			if expected == nil {
+				expected = names
+
+			} else if !reflect.DeepEqual(names, expected) {
+				t.Errorf("migrations differ from other engines: %v, expected %v", names, expected)
+			}
+		})
+	}
+}
+
+func TestMigratorUpDown(t *testing.T) {
+	db, dialect := openTestDB(t)
+	migrator, err := newMigrator(db, dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Current != status.Latest || len(status.Pending) > 0 {
+		t.Fatalf("unexpected status after up: %+v", status)
+	}
+	for version := status.Latest; version > 0; version-- {
+		mig, err := migrator.Down()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mig == nil || mig.Version != version {
+			t.Fatalf("expected version %d to be reverted, got %+v", version, mig)
+		}
+	}
+	if mig, err := migrator.Down(); err != nil || mig != nil {
+		t.Fatalf("expected nothing to revert, got %+v, %v", mig, err)
+	}
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != status.Latest {
+		t.Errorf("expected %d migrations to be applied again, got %d", status.Latest, len(applied))
+	}
+}
+
+func TestMySQLAppliedSchemaChange(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&mysql.MySQLError{Number: 1060, Message: "Duplicate column name"}, true},
+		{&mysql.MySQLError{Number: 1061, Message: "Duplicate key name"}, true},
+		{&mysql.MySQLError{Number: 1050, Message: "Table already exists"}, true},
+		{&mysql.MySQLError{Number: 1091, Message: "Can't DROP"}, true},
+		{fmt.Errorf("wrapped: %w", &mysql.MySQLError{Number: 1060}), true},
+		{&mysql.MySQLError{Number: 1064, Message: "Syntax error"}, false},
+		{fmt.Errorf("connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := (mysqlDialect{}).isAppliedSchemaChange(tt.err); got != tt.want {
+			t.Errorf("isAppliedSchemaChange(%v) = %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+DROP TABLE conomi_report;
+
+DROP TABLE conomi_report_group;
@@ -1,15 +1,15 @@
-CREATE TABLE conomi_report_group (
+CREATE TABLE IF NOT EXISTS conomi_report_group (
     id int(11) NOT NULL AUTO_INCREMENT,
     app varchar(50) NOT NULL,
     instance varchar(50),
     tag varchar(100),
     created datetime DEFAULT NOW() NOT NULL,
-    resolved_by_user_id int DEFAULT NULL,
     escalated tinyint(1) DEFAULT 0,
+    resolved_by_user_id int DEFAULT NULL,
     PRIMARY KEY (id)
 );
 
-CREATE TABLE conomi_report (
+CREATE TABLE IF NOT EXISTS conomi_report (
     id int(11) NOT NULL AUTO_INCREMENT,
     report_group_id int(11) NOT NULL REFERENCES conomi_report_group(id),
     severity varchar(50) NOT NULL,
@@ -18,4 +18,4 @@ CREATE TABLE conomi_report (
     args json,
     created datetime DEFAULT NOW() NOT NULL,
     PRIMARY KEY (id)
-);
\ No newline at end of file
+);
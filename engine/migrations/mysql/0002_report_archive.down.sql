@@ -0,0 +1,3 @@
+DROP INDEX conomi_report_created_idx ON conomi_report;
+
+DROP TABLE conomi_report_archive;
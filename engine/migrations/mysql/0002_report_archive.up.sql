@@ -0,0 +1,17 @@
+CREATE TABLE conomi_report_archive (
+    id int(11) NOT NULL,
+    report_group_id int(11) NOT NULL,
+    app varchar(50) NOT NULL,
+    instance varchar(50),
+    tag varchar(100),
+    severity varchar(50) NOT NULL,
+    subject text NOT NULL,
+    body text NOT NULL,
+    args json,
+    created datetime NOT NULL,
+    resolved_by_user_id int DEFAULT NULL,
+    archived datetime NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX conomi_report_created_idx ON conomi_report (created);
@@ -0,0 +1 @@
+DROP INDEX conomi_report_fulltext_idx ON conomi_report;
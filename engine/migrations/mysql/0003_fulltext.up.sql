@@ -0,0 +1 @@
+CREATE FULLTEXT INDEX conomi_report_fulltext_idx ON conomi_report (subject, body);
@@ -0,0 +1,3 @@
+ALTER TABLE conomi_report_group DROP COLUMN acknowledged;
+
+ALTER TABLE conomi_report_group DROP COLUMN acknowledged_by_user_id;
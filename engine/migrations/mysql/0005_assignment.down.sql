@@ -0,0 +1 @@
+ALTER TABLE conomi_report_group DROP COLUMN assigned_to_user_id;
@@ -0,0 +1,13 @@
+-- group timeline (comments and automatically recorded events)
+CREATE TABLE conomi_group_event (
+    id int(11) NOT NULL AUTO_INCREMENT,
+    report_group_id int(11) NOT NULL,
+    event_type varchar(50) NOT NULL,
+    user_id int DEFAULT NULL,
+    body text,
+    created datetime DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (id),
+    FOREIGN KEY (report_group_id) REFERENCES conomi_report_group(id) ON DELETE CASCADE
+);
+
+CREATE INDEX conomi_group_event_group_idx ON conomi_group_event (report_group_id, created);
@@ -0,0 +1,12 @@
+ALTER TABLE conomi_report_group ADD COLUMN snoozed_until datetime DEFAULT NULL;
+
+-- empty instance or tag matches any instance or tag of the app
+CREATE TABLE conomi_source_snooze (
+    app varchar(50) NOT NULL,
+    instance varchar(50) NOT NULL DEFAULT '',
+    tag varchar(100) NOT NULL DEFAULT '',
+    snoozed_until datetime NOT NULL,
+    user_id int DEFAULT NULL,
+    created datetime DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (app, instance, tag)
+);
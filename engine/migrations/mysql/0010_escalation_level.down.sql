@@ -0,0 +1,2 @@
+ALTER TABLE conomi_report_group DROP COLUMN escalated_at;
+ALTER TABLE conomi_report_group DROP COLUMN escalation_level;
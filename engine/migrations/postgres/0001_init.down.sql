@@ -0,0 +1,6 @@
+DROP TABLE conomi_report;
+
+DROP TABLE conomi_report_group;
+
+-- the "user" table is kept as it may be shared with other applications
+-- (it is created only if it does not exist)
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS conomi_report_group (
+    id SERIAL PRIMARY KEY,
+    app varchar(50) NOT NULL,
+    instance varchar(50),
+    tag varchar(100),
+    created timestamp with time zone DEFAULT NOW() NOT NULL,
+    escalated boolean DEFAULT FALSE,
+    resolved_by_user_id int DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS conomi_report (
+    id SERIAL PRIMARY KEY,
+    report_group_id int NOT NULL REFERENCES conomi_report_group(id),
+    severity varchar(50) NOT NULL,
+    subject text NOT NULL,
+    body text NOT NULL,
+    args jsonb,
+    created timestamp with time zone DEFAULT NOW() NOT NULL
+);
+
+-- `user` is a reserved word in PostgreSQL
+CREATE TABLE IF NOT EXISTS "user" (
+    id SERIAL PRIMARY KEY,
+    "user" varchar(255) NOT NULL UNIQUE
+);
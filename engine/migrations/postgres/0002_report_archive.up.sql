@@ -0,0 +1,16 @@
+CREATE TABLE conomi_report_archive (
+    id int PRIMARY KEY,
+    report_group_id int NOT NULL,
+    app varchar(50) NOT NULL,
+    instance varchar(50),
+    tag varchar(100),
+    severity varchar(50) NOT NULL,
+    subject text NOT NULL,
+    body text NOT NULL,
+    args jsonb,
+    created timestamp with time zone NOT NULL,
+    resolved_by_user_id int DEFAULT NULL,
+    archived timestamp with time zone NOT NULL
+);
+
+CREATE INDEX conomi_report_created_idx ON conomi_report (created);
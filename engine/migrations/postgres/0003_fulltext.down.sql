@@ -0,0 +1 @@
+DROP TABLE conomi_report_token;
@@ -0,0 +1 @@
+ALTER TABLE conomi_report_group ADD COLUMN assigned_to_user_id int DEFAULT NULL;
@@ -0,0 +1,2 @@
+-- indexes are dropped along with the table
+DROP TABLE conomi_group_event;
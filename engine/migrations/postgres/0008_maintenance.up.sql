@@ -0,0 +1,17 @@
+ALTER TABLE conomi_report ADD COLUMN in_maintenance boolean DEFAULT FALSE NOT NULL;
+
+-- one-off windows are given by start and end, recurring ones
+-- by a cron expression and a duration (empty instance matches
+-- any instance of the app)
+CREATE TABLE conomi_maintenance_window (
+    id SERIAL PRIMARY KEY,
+    app varchar(50) NOT NULL,
+    instance varchar(50) NOT NULL DEFAULT '',
+    start_time timestamp with time zone DEFAULT NULL,
+    end_time timestamp with time zone DEFAULT NULL,
+    cron varchar(100) DEFAULT NULL,
+    duration_mins int DEFAULT NULL,
+    description text,
+    user_id int DEFAULT NULL,
+    created timestamp with time zone DEFAULT NOW() NOT NULL
+);
@@ -0,0 +1,3 @@
+ALTER TABLE conomi_report_group ADD COLUMN escalation_level int NOT NULL DEFAULT 0;
+ALTER TABLE conomi_report_group ADD COLUMN escalated_at timestamp with time zone DEFAULT NULL;
+UPDATE conomi_report_group SET escalation_level = 1 WHERE escalated;
@@ -0,0 +1,2 @@
+ALTER TABLE conomi_report_group ADD COLUMN reminders int NOT NULL DEFAULT 0;
+ALTER TABLE conomi_report_group ADD COLUMN last_reminder timestamp with time zone DEFAULT NULL;
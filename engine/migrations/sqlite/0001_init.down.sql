@@ -0,0 +1,7 @@
+DROP TABLE conomi_report;
+
+DROP TABLE conomi_report_group;
+
+-- unlike the shared user databases of MySQL and PostgreSQL,
+-- the embedded user table belongs to conomi
+DROP TABLE user;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS conomi_report_group (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app varchar(50) NOT NULL,
+    instance varchar(50),
+    tag varchar(100),
+    created datetime DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    escalated boolean DEFAULT 0,
+    resolved_by_user_id int DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS conomi_report (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    report_group_id int NOT NULL REFERENCES conomi_report_group(id),
+    severity varchar(50) NOT NULL,
+    subject text NOT NULL,
+    body text NOT NULL,
+    args json,
+    created datetime DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- there is no shared user database in case of an embedded
+-- storage so we need our own (minimal) one
+CREATE TABLE IF NOT EXISTS user (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user varchar(255) NOT NULL UNIQUE
+);
@@ -0,0 +1,3 @@
+DROP INDEX conomi_report_created_idx;
+
+DROP TABLE conomi_report_archive;
@@ -0,0 +1,10 @@
+-- a simple inverted index used for full-text search
+-- (reports inserted before this migration are indexed
+-- by the migration itself, see `migrationSteps`)
+CREATE TABLE conomi_report_token (
+    report_id int NOT NULL REFERENCES conomi_report(id) ON DELETE CASCADE,
+    token varchar(100) NOT NULL,
+    PRIMARY KEY (token, report_id)
+);
+
+CREATE INDEX conomi_report_token_report_idx ON conomi_report_token (report_id);
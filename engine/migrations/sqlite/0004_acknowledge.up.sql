@@ -0,0 +1,3 @@
+ALTER TABLE conomi_report_group ADD COLUMN acknowledged_by_user_id int DEFAULT NULL;
+
+ALTER TABLE conomi_report_group ADD COLUMN acknowledged datetime DEFAULT NULL;
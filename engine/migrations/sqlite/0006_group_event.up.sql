@@ -0,0 +1,11 @@
+-- group timeline (comments and automatically recorded events)
+CREATE TABLE conomi_group_event (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    report_group_id int NOT NULL REFERENCES conomi_report_group(id) ON DELETE CASCADE,
+    event_type varchar(50) NOT NULL,
+    user_id int DEFAULT NULL,
+    body text,
+    created datetime DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX conomi_group_event_group_idx ON conomi_group_event (report_group_id, created);
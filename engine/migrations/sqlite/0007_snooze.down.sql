@@ -0,0 +1,3 @@
+DROP TABLE conomi_source_snooze;
+
+ALTER TABLE conomi_report_group DROP COLUMN snoozed_until;
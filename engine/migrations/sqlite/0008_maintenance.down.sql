@@ -0,0 +1,3 @@
+DROP TABLE conomi_maintenance_window;
+
+ALTER TABLE conomi_report DROP COLUMN in_maintenance;
@@ -0,0 +1,17 @@
+ALTER TABLE conomi_report ADD COLUMN in_maintenance boolean DEFAULT 0 NOT NULL;
+
+-- one-off windows are given by start and end, recurring ones
+-- by a cron expression and a duration (empty instance matches
+-- any instance of the app)
+CREATE TABLE conomi_maintenance_window (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app varchar(50) NOT NULL,
+    instance varchar(50) NOT NULL DEFAULT '',
+    start_time datetime DEFAULT NULL,
+    end_time datetime DEFAULT NULL,
+    cron varchar(100) DEFAULT NULL,
+    duration_mins int DEFAULT NULL,
+    description text,
+    user_id int DEFAULT NULL,
+    created datetime DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE conomi_report_group ADD COLUMN deescalated datetime DEFAULT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE conomi_report_group DROP COLUMN last_reminder;
+ALTER TABLE conomi_report_group DROP COLUMN reminders;
@@ -18,39 +18,104 @@ package engine
 
 import (
 	"database/sql"
+	"errors"
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
 )
 
-type DBConf struct {
-	Host     string `json:"host"`
-	Port     int    `json:"port"`
-	Name     string `json:"name"`
-	User     string `json:"user"`
-	Password string `json:"password"`
-	PoolSize int    `json:"poolSize"`
+type mysqlDialect struct{}
+
+func (d mysqlDialect) migrationsDir() string {
+	return DriverMySQL
+}
+
+func (d mysqlDialect) schemaVersionTable() string {
+	return `CREATE TABLE IF NOT EXISTS conomi_schema_version (
+		version int NOT NULL,
+		name varchar(255) NOT NULL,
+		applied datetime NOT NULL,
+		PRIMARY KEY (version)
+	)`
+}
+
+func (d mysqlDialect) rebind(query string) string {
+	return query
+}
+
+func (d mysqlDialect) insert(db sqlExecutor, query string, args ...any) (int64, error) {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (d mysqlDialect) userTable() string {
+	return "user"
+}
+
+func (d mysqlDialect) timeCmp(column, op string) string {
+	return column + " " + op + " ?"
+}
+
+func (d mysqlDialect) hasFullText() bool {
+	return true
+}
+
+func (d mysqlDialect) jsonValue(column string, path []string) (string, any) {
+	return "JSON_UNQUOTE(JSON_EXTRACT(" + column + ", ?))", jsonPath(path)
+}
+
+func (d mysqlDialect) recentCond(column string) string {
+	return column + " > NOW() - INTERVAL 1 DAY"
+}
+
+func (d mysqlDialect) transactionalDDL() bool {
+	return false
 }
 
-func Open(conf *DBConf, createTables bool) (*sql.DB, error) {
+func (d mysqlDialect) isAppliedSchemaChange(err error) bool {
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		return false
+	}
+	switch myErr.Number {
+	case 1050, // table already exists
+		1051, // unknown table
+		1060, // duplicate column name
+		1061, // duplicate key name
+		1091: // can't drop, column or key does not exist
+		return true
+	}
+	return false
+}
+
+func mysqlConfig(conf *DBConf) *mysql.Config {
 	mconf := mysql.NewConfig()
 	mconf.Net = "tcp"
 	mconf.Addr = conf.Host
+	if conf.Port > 0 {
+		mconf.Addr = net.JoinHostPort(conf.Host, strconv.Itoa(conf.Port))
+	}
 	mconf.User = conf.User
 	mconf.Passwd = conf.Password
 	mconf.DBName = conf.Name
 	mconf.ParseTime = true
 	mconf.Loc = time.Local
 	mconf.Params = map[string]string{"autocommit": "true"}
-	db, err := sql.Open("mysql", mconf.FormatDSN())
+	return mconf
+}
+
+func openMySQL(conf *DBConf) (*sql.DB, error) {
+	db, err := sql.Open("mysql", mysqlConfig(conf).FormatDSN())
 	if err != nil {
 		return nil, err
 	}
-	if createTables {
-		err = initDatabase(db)
-		if err != nil {
-			return nil, err
-		}
+	if conf.PoolSize > 0 {
+		db.SetMaxOpenConns(conf.PoolSize)
 	}
 	return db, nil
 }
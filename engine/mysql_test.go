@@ -0,0 +1,53 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"testing"
+)
+
+func TestMySQLConfigAddr(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		port int
+		want string
+	}{
+		{"host only", "dbserver", 0, "dbserver"},
+		{"host with port", "dbserver:3307", 0, "dbserver:3307"},
+		{"port", "dbserver", 3307, "dbserver:3307"},
+		{"ipv6", "::1", 3307, "[::1]:3307"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mysqlConfig(&DBConf{Host: tt.host, Port: tt.port}).Addr; got != tt.want {
+				t.Errorf("Addr = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenMySQLPoolSize(t *testing.T) {
+	db, err := openMySQL(&DBConf{Host: "dbserver", Name: "conomi", PoolSize: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if got := db.Stats().MaxOpenConnections; got != 5 {
+		t.Errorf("expected max. 5 open connections, got %d", got)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+type postgresDialect struct{}
+
+func (d postgresDialect) migrationsDir() string {
+	return DriverPostgres
+}
+
+func (d postgresDialect) schemaVersionTable() string {
+	return `CREATE TABLE IF NOT EXISTS conomi_schema_version (
+		version int PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		applied timestamp with time zone NOT NULL
+	)`
+}
+
+// rebind replaces `?` placeholders with PostgreSQL `$n` ones.
+// Question marks within quoted string literals and quoted
+// identifiers are left untouched.
+func (d postgresDialect) rebind(query string) string {
+	var ans strings.Builder
+	var n int
+	var quote rune // the currently open quote (zero if none)
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			// an escaped quote ('' or "") just closes and reopens the quoting
+			if c == quote {
+				quote = 0
+			}
+			ans.WriteRune(c)
+		case c == '\'' || c == '"':
+			quote = c
+			ans.WriteRune(c)
+		case c == '?':
+			n++
+			ans.WriteString("$" + strconv.Itoa(n))
+		default:
+			ans.WriteRune(c)
+		}
+	}
+	return ans.String()
+}
+
+func (d postgresDialect) insert(db sqlExecutor, query string, args ...any) (int64, error) {
+	var id int64
+	err := db.QueryRow(d.rebind(query)+" RETURNING id", args...).Scan(&id)
+	return id, err
+}
+
+func (d postgresDialect) userTable() string {
+	// `user` is a reserved word in PostgreSQL
+	return `"user"`
+}
+
+func (d postgresDialect) timeCmp(column, op string) string {
+	return column + " " + op + " ?"
+}
+
+func (d postgresDialect) hasFullText() bool {
+	return false
+}
+
+func (d postgresDialect) jsonValue(column string, path []string) (string, any) {
+	return column + " #>> ?", "{" + strings.Join(path, ",") + "}"
+}
+
+func (d postgresDialect) recentCond(column string) string {
+	return column + " > NOW() - INTERVAL '1 day'"
+}
+
+func (d postgresDialect) transactionalDDL() bool {
+	return true
+}
+
+func (d postgresDialect) isAppliedSchemaChange(err error) bool {
+	return false
+}
+
+func openPostgres(conf *DBConf) (*sql.DB, error) {
+	port := conf.Port
+	if port == 0 {
+		port = 5432
+	}
+	params := []string{
+		fmt.Sprintf("host='%s'", escapeConnParam(conf.Host)),
+		fmt.Sprintf("port=%d", port),
+		fmt.Sprintf("dbname='%s'", escapeConnParam(conf.Name)),
+		fmt.Sprintf("user='%s'", escapeConnParam(conf.User)),
+		fmt.Sprintf("password='%s'", escapeConnParam(conf.Password)),
+	}
+	if conf.SSLMode != "" {
+		params = append(params, fmt.Sprintf("sslmode='%s'", escapeConnParam(conf.SSLMode)))
+	}
+	return sql.Open("postgres", strings.Join(params, " "))
+}
+
+// escapeConnParam escapes a value to be used
+// within a quoted connection string parameter
+func escapeConnParam(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v)
+}
@@ -0,0 +1,218 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/rs/zerolog/log"
+)
+
+// emptiedGroupsBatchSize limits the number of report IDs
+// passed to a single query in CountEmptiedGroups
+const emptiedGroupsBatchSize = 500
+
+func mkPlaceholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+func (rdb *ReportsDatabase) FindExpiredReports(
+	severity general.SeverityLevel,
+	olderThan time.Time,
+	onlyResolved bool,
+	offset, limit int,
+) ([]int, error) {
+	whereParts := []string{"cr.severity = ?", rdb.dialect.timeCmp("cr.created", "<")}
+	if onlyResolved {
+		whereParts = append(whereParts, "crg.resolved_by_user_id IS NOT NULL")
+	}
+	sql1 := "SELECT cr.id " +
+		"FROM conomi_report AS cr " +
+		"JOIN conomi_report_group AS crg ON crg.id = cr.report_group_id " +
+		"WHERE " + strings.Join(whereParts, " AND ") + " " +
+		"ORDER BY cr.id LIMIT ? OFFSET ?"
+	log.Debug().Str("sql", sql1).Msgf("going to find expired reports of severity %s", severity)
+	rows, err := rdb.query(sql1, severity, olderThan, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expired reports: %w", err)
+	}
+	defer rows.Close()
+	ans := make([]int, 0, limit)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to find expired reports: %w", err)
+		}
+		ans = append(ans, id)
+	}
+	return ans, nil
+}
+
+func (rdb *ReportsDatabase) FindOversizedGroups(maxReports int, onlyResolved bool) ([]int, error) {
+	whereClause := ""
+	if onlyResolved {
+		whereClause = "WHERE crg.resolved_by_user_id IS NOT NULL "
+	}
+	sql1 := "SELECT crg.id " +
+		"FROM conomi_report_group AS crg " +
+		"JOIN conomi_report AS cr ON crg.id = cr.report_group_id " +
+		whereClause +
+		"GROUP BY crg.id HAVING COUNT(*) > ?"
+	log.Debug().Str("sql", sql1).Msgf("going to find groups with more than %d reports", maxReports)
+	rows, err := rdb.query(sql1, maxReports)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find oversized groups: %w", err)
+	}
+	defer rows.Close()
+	ans := make([]int, 0, 100)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to find oversized groups: %w", err)
+		}
+		ans = append(ans, id)
+	}
+	return ans, nil
+}
+
+func (rdb *ReportsDatabase) FindExcessReports(groupID int, keep int, offset, limit int) ([]int, error) {
+	sql1 := "SELECT id FROM conomi_report WHERE report_group_id = ? " +
+		"ORDER BY created DESC, id DESC LIMIT ? OFFSET ?"
+	log.Debug().Str("sql", sql1).Msgf("going to find excess reports of group %d", groupID)
+	rows, err := rdb.query(sql1, groupID, limit, keep+offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find excess reports: %w", err)
+	}
+	defer rows.Close()
+	ans := make([]int, 0, limit)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to find excess reports: %w", err)
+		}
+		ans = append(ans, id)
+	}
+	return ans, nil
+}
+
+func (rdb *ReportsDatabase) DeleteReports(reportIDs []int, archive bool) error {
+	if len(reportIDs) == 0 {
+		return nil
+	}
+	args := make([]any, len(reportIDs))
+	for i, id := range reportIDs {
+		args[i] = id
+	}
+	tx, err := rdb.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to delete reports: %w", err)
+	}
+	if archive {
+		sql1 := "INSERT INTO conomi_report_archive " +
+			"(id, report_group_id, app, instance, tag, severity, subject, body, args, created, resolved_by_user_id, archived) " +
+			"SELECT cr.id, crg.id, crg.app, crg.instance, crg.tag, cr.severity, cr.subject, cr.body, cr.args, cr.created, crg.resolved_by_user_id, ? " +
+			"FROM conomi_report AS cr " +
+			"JOIN conomi_report_group AS crg ON crg.id = cr.report_group_id " +
+			"WHERE cr.id IN (" + mkPlaceholders(len(reportIDs)) + ")"
+		log.Debug().Str("sql", sql1).Msgf("going to archive %d reports", len(reportIDs))
+		if _, err := tx.Exec(rdb.dialect.rebind(sql1), append([]any{time.Now()}, args...)...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to archive reports: %w", err)
+		}
+	}
+	sql1 := "DELETE FROM conomi_report WHERE id IN (" + mkPlaceholders(len(reportIDs)) + ")"
+	log.Debug().Str("sql", sql1).Msgf("going to delete %d reports", len(reportIDs))
+	if _, err := tx.Exec(rdb.dialect.rebind(sql1), args...); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete reports: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete reports: %w", err)
+	}
+	return nil
+}
+
+func (rdb *ReportsDatabase) DeleteEmptyGroups() (int, error) {
+	sql1 := "DELETE FROM conomi_report_group " +
+		"WHERE resolved_by_user_id IS NOT NULL AND NOT EXISTS (" +
+		"SELECT 1 FROM conomi_report AS cr WHERE cr.report_group_id = conomi_report_group.id)"
+	log.Debug().Str("sql", sql1).Msg("going to delete empty resolved groups")
+	result, err := rdb.exec(sql1)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete empty groups: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete empty groups: %w", err)
+	}
+	return int(deleted), nil
+}
+
+func (rdb *ReportsDatabase) CountEmptiedGroups(reportIDs []int) (int, error) {
+	removed := make(map[int]int)
+	for i := 0; i < len(reportIDs); i += emptiedGroupsBatchSize {
+		batch := reportIDs[i:min(i+emptiedGroupsBatchSize, len(reportIDs))]
+		args := make([]any, len(batch))
+		for j, id := range batch {
+			args[j] = id
+		}
+		sql1 := "SELECT report_group_id, COUNT(*) FROM conomi_report " +
+			"WHERE id IN (" + mkPlaceholders(len(batch)) + ") " +
+			"GROUP BY report_group_id"
+		log.Debug().Str("sql", sql1).Msgf("going to count groups of %d reports", len(batch))
+		rows, err := rdb.query(sql1, args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count emptied groups: %w", err)
+		}
+		for rows.Next() {
+			var groupID, count int
+			if err := rows.Scan(&groupID, &count); err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("failed to count emptied groups: %w", err)
+			}
+			removed[groupID] += count
+		}
+		rows.Close()
+	}
+	// resolved groups which are already empty are counted too
+	// as DeleteEmptyGroups removes them as well
+	sql1 := "SELECT crg.id, COUNT(cr.id) " +
+		"FROM conomi_report_group AS crg " +
+		"LEFT JOIN conomi_report AS cr ON crg.id = cr.report_group_id " +
+		"WHERE crg.resolved_by_user_id IS NOT NULL " +
+		"GROUP BY crg.id"
+	log.Debug().Str("sql", sql1).Msg("going to count reports of resolved groups")
+	rows, err := rdb.query(sql1)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count emptied groups: %w", err)
+	}
+	defer rows.Close()
+	var ans int
+	for rows.Next() {
+		var groupID, count int
+		if err := rows.Scan(&groupID, &count); err != nil {
+			return 0, fmt.Errorf("failed to count emptied groups: %w", err)
+		}
+		if count == removed[groupID] {
+			ans++
+		}
+	}
+	return ans, nil
+}
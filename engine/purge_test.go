@@ -0,0 +1,65 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+func TestCountEmptiedGroups(t *testing.T) {
+	rdb, userID := newTestStore(t)
+	resolve := func(reports []*general.Report) {
+		if err := rdb.ResolveGroup(reports[0].GroupID, userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	warnings := []general.SeverityLevel{general.SeverityLevelWarning, general.SeverityLevelWarning}
+	emptied := insertTestReports(t, rdb, general.SourceID{App: "app1"}, 0, warnings...)
+	resolve(emptied)
+	partial := insertTestReports(t, rdb, general.SourceID{App: "app2"}, 0, warnings...)
+	resolve(partial)
+	untouched := insertTestReports(t, rdb, general.SourceID{App: "app3"}, 0, warnings...)
+	resolve(untouched)
+	open := insertTestReports(t, rdb, general.SourceID{App: "app4"}, 0, warnings...)
+
+	reportIDs := []int{emptied[0].ID, emptied[1].ID, partial[0].ID, open[0].ID, open[1].ID}
+	count, err := rdb.CountEmptiedGroups(reportIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 emptied group, got %d", count)
+	}
+
+	if err := rdb.DeleteReports(reportIDs, false); err != nil {
+		t.Fatal(err)
+	}
+	// already empty resolved groups are counted too
+	count, err = rdb.CountEmptiedGroups(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := rdb.DeleteEmptyGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != deleted || deleted != 1 {
+		t.Errorf("expected 1 counted and deleted group, got %d and %d", count, deleted)
+	}
+}
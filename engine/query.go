@@ -17,121 +17,135 @@
 package engine
 
 import (
-	"context"
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/czcorpus/conomi/general"
 	"github.com/rs/zerolog/log"
 )
 
-// ReportsDatabase
-// note: the lifecycle of the instance
-// is "per request"
+// ReportsDatabase is an SQL implementation of ReportStore.
+// Engine specific parts of queries are provided by the dialect.
 type ReportsDatabase struct {
-	db  *sql.DB
-	ctx context.Context
+	db      *sql.DB
+	dialect sqlDialect
 }
 
-func (rdb *ReportsDatabase) updateGroupID(report *general.Report) error {
+// sourceCond provides conditions matching groups of a specified source
+func sourceCond(sourceID general.SourceID) ([]string, []any) {
 	whereClause, whereValues := make([]string, 0, 4), make([]any, 0, 3)
-	whereClause, whereValues = append(whereClause, "app = ?"), append(whereValues, report.SourceID.App)
-	if report.SourceID.Instance != "" {
-		whereClause, whereValues = append(whereClause, "instance = ?"), append(whereValues, report.SourceID.Instance)
+	whereClause, whereValues = append(whereClause, "app = ?"), append(whereValues, sourceID.App)
+	if sourceID.Instance != "" {
+		whereClause, whereValues = append(whereClause, "instance = ?"), append(whereValues, sourceID.Instance)
 	} else {
 		whereClause = append(whereClause, "instance IS NULL")
 	}
-	if report.SourceID.Tag != "" {
-		whereClause, whereValues = append(whereClause, "tag = ?"), append(whereValues, report.SourceID.Tag)
+	if sourceID.Tag != "" {
+		whereClause, whereValues = append(whereClause, "tag = ?"), append(whereValues, sourceID.Tag)
 	} else {
 		whereClause = append(whereClause, "tag IS NULL")
 	}
+	return whereClause, whereValues
+}
+
+func (rdb *ReportsDatabase) updateGroupID(db sqlExecutor, report *general.Report) error {
+	whereClause, whereValues := sourceCond(report.SourceID)
 	whereClause = append(whereClause, "resolved_by_user_id IS NULL")
 
 	sql1 := "SELECT id FROM conomi_report_group " +
 		"WHERE " + strings.Join(whereClause, " AND ") + " LIMIT 1"
 
 	log.Debug().Str("sql", sql1).Msgf("going to SELECT conomi_report_group WHERE app = %s, instance = %s, tag = %s", report.SourceID.App, report.SourceID.Instance, report.SourceID.Tag)
-	row := rdb.db.QueryRow(sql1, whereValues...)
+	row := db.QueryRow(rdb.dialect.rebind(sql1), whereValues...)
 	return row.Scan(&report.GroupID)
 }
 
-func (rdb *ReportsDatabase) assignNewGroup(report *general.Report) error {
+func (rdb *ReportsDatabase) assignNewGroup(db sqlExecutor, report *general.Report) error {
 	sql1 := "INSERT INTO conomi_report_group (app, instance, tag, created) VALUES (?,?,?,?)"
 	log.Debug().Str("sql", sql1).Msgf("going to INSERT conomi_report_group WHERE app = %s, instance = %s, tag = %s", report.SourceID.App, report.SourceID.Instance, report.SourceID.Tag)
 	instance := sql.NullString{String: report.SourceID.Instance, Valid: report.SourceID.Instance != ""}
 	tag := sql.NullString{String: report.SourceID.Tag, Valid: report.SourceID.Tag != ""}
-	result, err := rdb.db.Exec(sql1, report.SourceID.App, instance, tag, report.Created)
+	groupID, err := rdb.dialect.insert(db, sql1, report.SourceID.App, instance, tag, report.Created)
 	if err != nil {
 		return fmt.Errorf("failed to assign new group: %w", err)
 	}
-	groupID, err := result.LastInsertId()
+	report.GroupID = int(groupID)
+	err = rdb.recordGroupEvent(db, &general.GroupEvent{
+		GroupID: report.GroupID,
+		Type:    general.GroupEventCreated,
+		UserID:  -1,
+		Created: report.Created,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to assign new group: %w", err)
 	}
-	report.GroupID = int(groupID)
 	return nil
 }
 
+// InsertReport stores the report (along with its search index entries)
+// within a single transaction. A new group is created if there is
+// no open group of the report's source.
 func (rdb *ReportsDatabase) InsertReport(report *general.Report) error {
-	err := rdb.updateGroupID(report)
-	if err == sql.ErrNoRows {
-		if err := rdb.assignNewGroup(report); err != nil {
-			return fmt.Errorf("failed to insert report: %w", err)
-		}
-	} else if err != nil {
-		return fmt.Errorf("failed to insert report: %w", err)
-	}
-
-	sql1 := "INSERT INTO conomi_report (report_group_id, severity, subject, body, args, created) VALUES (?,?,?,?,?,?)"
 	entry, err := NewReportSQL(report)
 	if err != nil {
 		return fmt.Errorf("failed to insert report: %w", err)
 	}
-	log.Debug().Str("sql", sql1).Msg("going to INSERT report")
-	result, err := rdb.db.Exec(sql1, entry.GroupID, entry.Severity, entry.Subject, entry.Body, entry.Args, entry.Created)
-	if err != nil {
-		return fmt.Errorf("failed to insert report: %w", err)
-	}
-	reportID, err := result.LastInsertId()
+	err = rdb.withTx(func(tx *sql.Tx) error {
+		err := rdb.updateGroupID(tx, report)
+		if err == sql.ErrNoRows {
+			if err := rdb.assignNewGroup(tx, report); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+		sql1 := "INSERT INTO conomi_report (report_group_id, severity, subject, body, args, created, in_maintenance) VALUES (?,?,?,?,?,?,?)"
+		log.Debug().Str("sql", sql1).Msg("going to INSERT report")
+		reportID, err := rdb.dialect.insert(tx, sql1, report.GroupID, entry.Severity, entry.Subject, entry.Body, entry.Args, entry.Created, entry.InMaintenance)
+		if err != nil {
+			return err
+		}
+		report.ID = int(reportID)
+		if !rdb.dialect.hasFullText() {
+			return indexReport(tx, rdb.dialect, report.ID, report.Subject, report.Body)
+		}
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to insert report: %w", err)
 	}
-	report.ID = int(reportID)
 	return nil
 }
 
-func (rdb *ReportsDatabase) ListReports(sourceID general.SourceID, resolved bool) ([]*general.Report, error) {
-	whereParts := make([]string, 0, 4)
-	whereValues := make([]any, 0, 3)
-	if !resolved {
-		whereParts = append(whereParts, "resolved_by_user_id IS NULL")
+func (rdb *ReportsDatabase) ListReports(args ListReportsArgs) ([]*general.Report, error) {
+	whereParts, whereValues := args.filter(rdb.dialect)
+	orderDir, cmpOp := "DESC", "<"
+	if args.isAscending() {
+		orderDir, cmpOp = "ASC", ">"
 	}
-	if sourceID.App != "" {
-		whereParts = append(whereParts, "app = ?")
-		whereValues = append(whereValues, sourceID.App)
+	if args.Cursor != nil {
+		whereParts = append(
+			whereParts,
+			"("+rdb.dialect.timeCmp("cr.created", cmpOp)+" OR ("+
+				rdb.dialect.timeCmp("cr.created", "=")+" AND cr.id "+cmpOp+" ?))",
+		)
+		whereValues = append(whereValues, args.Cursor.Created, args.Cursor.Created, args.Cursor.ID)
 	}
-	if sourceID.Instance != "" {
-		whereParts = append(whereParts, "instance = ?")
-		whereValues = append(whereValues, sourceID.Instance)
-	}
-	if sourceID.Tag != "" {
-		whereParts = append(whereParts, "tag = ?")
-		whereValues = append(whereValues, sourceID.Tag)
+	limitClause := ""
+	if args.Limit > 0 {
+		limitClause = " LIMIT ?"
+		whereValues = append(whereValues, args.Limit)
 	}
-	whereClause := ""
-	if len(whereParts) > 0 {
-		whereClause = "WHERE " + strings.Join(whereParts, " AND ") + " "
-	}
-	sql1 := "SELECT cr.id, crg.id, crg.app, crg.instance, crg.tag, cr.severity, cr.subject, cr.body, cr.args, cr.created, crg.resolved_by_user_id, us.user, crg.escalated " +
+	sql1 := "SELECT cr.id, crg.id, crg.app, crg.instance, crg.tag, cr.severity, cr.subject, cr.body, cr.args, cr.created, crg.resolved_by_user_id, us.user, crg.escalated, cr.in_maintenance " +
 		"FROM conomi_report_group AS crg " +
 		"JOIN conomi_report AS cr ON crg.id = cr.report_group_id " +
-		"LEFT JOIN user AS us ON resolved_by_user_id = us.id " +
-		whereClause +
-		"ORDER BY created DESC"
+		"LEFT JOIN " + rdb.dialect.userTable() + " AS us ON resolved_by_user_id = us.id " +
+		mkWhereClause(whereParts) +
+		"ORDER BY cr.created " + orderDir + ", cr.id " + orderDir + limitClause
 	log.Debug().Str("sql", sql1).Msg("going to SELECT conomi_report")
-	rows, err := rdb.db.Query(sql1, whereValues...)
+	rows, err := rdb.query(sql1, whereValues...)
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +153,7 @@ func (rdb *ReportsDatabase) ListReports(sourceID general.SourceID, resolved bool
 	ans := make([]*general.Report, 0, 100)
 	for rows.Next() {
 		entry := &reportSQL{}
-		err := rows.Scan(&entry.ID, &entry.GroupID, &entry.App, &entry.Instance, &entry.Tag, &entry.Severity, &entry.Subject, &entry.Body, &entry.Args, &entry.Created, &entry.ResolvedByUserID, &entry.ResolvedByUserName, &entry.Escalated)
+		err := rows.Scan(&entry.ID, &entry.GroupID, &entry.App, &entry.Instance, &entry.Tag, &entry.Severity, &entry.Subject, &entry.Body, &entry.Args, &entry.Created, &entry.ResolvedByUserID, &entry.ResolvedByUserName, &entry.Escalated, &entry.InMaintenance)
 		if err != nil {
 			return nil, err
 		}
@@ -155,16 +169,30 @@ func (rdb *ReportsDatabase) ListReports(sourceID general.SourceID, resolved bool
 	return ans, nil
 }
 
+func (rdb *ReportsDatabase) CountReports(args ListReportsArgs) (int, error) {
+	whereParts, whereValues := args.filter(rdb.dialect)
+	sql1 := "SELECT COUNT(*) " +
+		"FROM conomi_report_group AS crg " +
+		"JOIN conomi_report AS cr ON crg.id = cr.report_group_id " +
+		mkWhereClause(whereParts)
+	log.Debug().Str("sql", sql1).Msg("going to count conomi_report")
+	var ans int
+	if err := rdb.queryRow(sql1, whereValues...).Scan(&ans); err != nil {
+		return 0, err
+	}
+	return ans, nil
+}
+
 func (rdb *ReportsDatabase) SelectReport(reportID int) (*general.Report, error) {
-	sql1 := "SELECT cr.id, crg.id, crg.app, crg.instance, crg.tag, cr.severity, cr.subject, cr.body, cr.args, cr.created, crg.resolved_by_user_id, us.user, crg.escalated " +
+	sql1 := "SELECT cr.id, crg.id, crg.app, crg.instance, crg.tag, cr.severity, cr.subject, cr.body, cr.args, cr.created, crg.resolved_by_user_id, us.user, crg.escalated, cr.in_maintenance " +
 		"FROM conomi_report_group AS crg " +
 		"JOIN conomi_report AS cr ON crg.id = cr.report_group_id " +
-		"LEFT JOIN user AS us ON resolved_by_user_id = us.id " +
+		"LEFT JOIN " + rdb.dialect.userTable() + " AS us ON resolved_by_user_id = us.id " +
 		"WHERE cr.id = ? LIMIT 1"
 	log.Debug().Str("sql", sql1).Msgf("going to SELECT conomi_reports WHERE id = %d", reportID)
 	entry := &reportSQL{}
-	row := rdb.db.QueryRow(sql1, reportID)
-	if err := row.Scan(&entry.ID, &entry.GroupID, &entry.App, &entry.Instance, &entry.Tag, &entry.Severity, &entry.Subject, &entry.Body, &entry.Args, &entry.Created, &entry.ResolvedByUserID, &entry.ResolvedByUserName, &entry.Escalated); err != nil {
+	row := rdb.queryRow(sql1, reportID)
+	if err := row.Scan(&entry.ID, &entry.GroupID, &entry.App, &entry.Instance, &entry.Tag, &entry.Severity, &entry.Subject, &entry.Body, &entry.Args, &entry.Created, &entry.ResolvedByUserID, &entry.ResolvedByUserName, &entry.Escalated, &entry.InMaintenance); err != nil {
 		return nil, err
 	}
 	if err := entry.Severity.Validate(); err != nil {
@@ -173,43 +201,163 @@ func (rdb *ReportsDatabase) SelectReport(reportID int) (*general.Report, error)
 	return entry.Export()
 }
 
+// updateOpenGroup updates an unresolved group and records the change
+// to the group timeline. Already resolved groups are left untouched
+// (in such case, false is returned).
+func (rdb *ReportsDatabase) updateOpenGroup(groupID int, setClause string, event *general.GroupEvent, values ...any) (bool, error) {
+	var updated bool
+	err := rdb.withTx(func(tx *sql.Tx) error {
+		sql1 := "UPDATE conomi_report_group " +
+			"SET " + setClause + " " +
+			"WHERE resolved_by_user_id IS NULL AND id = ?"
+		log.Debug().Str("sql", sql1).Msgf("going to update group WHERE id = %d", groupID)
+		res, err := tx.Exec(rdb.dialect.rebind(sql1), append(values, groupID)...)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil || affected == 0 {
+			return err
+		}
+		updated = true
+		event.GroupID = groupID
+		return rdb.recordGroupEvent(tx, event)
+	})
+	return updated, err
+}
+
 func (rdb *ReportsDatabase) EscalateGroup(groupID int) error {
-	sql1 := "UPDATE conomi_report_group AS crg " +
-		"SET crg.escalated = 1 " +
-		"WHERE crg.resolved_by_user_id IS NULL AND crg.id = ?"
-	log.Debug().Str("sql", sql1).Msgf("going to escalate group WHERE id = %d", groupID)
-	_, err := rdb.db.Exec(sql1, groupID)
+	_, err := rdb.updateOpenGroup(
+		groupID,
+		"escalated = ?, escalation_level = ?, escalated_at = ?, reminders = 0, last_reminder = NULL",
+		&general.GroupEvent{Type: general.GroupEventEscalated, UserID: -1},
+		true, 1, time.Now(),
+	)
 	if err != nil {
 		return fmt.Errorf("failed to escalate group: %w", err)
 	}
 	return nil
 }
 
+// PromoteGroup raises the escalation level of an escalated group
+func (rdb *ReportsDatabase) PromoteGroup(groupID int, level int) error {
+	_, err := rdb.updateOpenGroup(
+		groupID,
+		"escalation_level = ?",
+		&general.GroupEvent{
+			Type:   general.GroupEventEscalated,
+			UserID: -1,
+			Body:   fmt.Sprintf("Escalation level %d", level),
+		},
+		level,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to promote group: %w", err)
+	}
+	return nil
+}
+
+// RecordReminder remembers that a reminder of the group escalation
+// has been sent
+func (rdb *ReportsDatabase) RecordReminder(groupID int, reminder int) error {
+	_, err := rdb.updateOpenGroup(
+		groupID,
+		"reminders = ?, last_reminder = ?",
+		&general.GroupEvent{
+			Type:   general.GroupEventNotification,
+			UserID: -1,
+			Body:   fmt.Sprintf("Reminder %d sent", reminder),
+		},
+		reminder, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record reminder: %w", err)
+	}
+	return nil
+}
+
+// DeescalateGroup clears the escalation flag of an unresolved group
+// and remembers when it happened
+func (rdb *ReportsDatabase) DeescalateGroup(groupID int) error {
+	_, err := rdb.updateOpenGroup(
+		groupID,
+		"escalated = ?, escalation_level = ?, deescalated = ?",
+		&general.GroupEvent{Type: general.GroupEventDeescalated, UserID: -1},
+		false, 0, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to de-escalate group: %w", err)
+	}
+	return nil
+}
+
 func (rdb *ReportsDatabase) ResolveGroup(groupID int, userID int) error {
-	sql1 := "UPDATE conomi_report_group AS crg " +
-		"SET crg.resolved_by_user_id = ? " +
-		"WHERE crg.resolved_by_user_id IS NULL AND crg.id = ?"
-	log.Debug().Str("sql", sql1).Msgf("going to resolve group WHERE id = %d", groupID)
-	_, err := rdb.db.Exec(sql1, userID, groupID)
+	_, err := rdb.updateOpenGroup(
+		groupID,
+		"resolved_by_user_id = ?",
+		&general.GroupEvent{Type: general.GroupEventResolved, UserID: userID},
+		userID,
+	)
 	if err != nil {
 		return fmt.Errorf("failed to resolve group: %w", err)
 	}
 	return nil
 }
 
+// ListReportTimes provides creation times of reports of unresolved
+// groups created since `from` ordered from the oldest ones. For each
+// source and severity, only `limit` latest reports are returned.
+// Reports obtained during maintenance are skipped.
+func (rdb *ReportsDatabase) ListReportTimes(from time.Time, limit int) ([]*ReportTime, error) {
+	sql1 := "SELECT app, instance, tag, severity, created FROM (" +
+		"SELECT crg.app AS app, crg.instance AS instance, crg.tag AS tag, cr.severity AS severity, cr.created AS created, cr.id AS id, " +
+		"ROW_NUMBER() OVER (PARTITION BY crg.id, cr.severity ORDER BY cr.created DESC, cr.id DESC) AS pos " +
+		"FROM conomi_report_group AS crg " +
+		"JOIN conomi_report AS cr ON crg.id = cr.report_group_id " +
+		"WHERE crg.resolved_by_user_id IS NULL AND cr.in_maintenance = ? AND " + rdb.dialect.timeCmp("cr.created", ">=") +
+		") AS latest WHERE pos <= ? ORDER BY created, id"
+	log.Debug().Str("sql", sql1).Msg("going to SELECT conomi_report times")
+	rows, err := rdb.query(sql1, false, from, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list report times: %w", err)
+	}
+	defer rows.Close()
+	ans := make([]*ReportTime, 0, 100)
+	for rows.Next() {
+		item := &ReportTime{}
+		var instance, tag sql.NullString
+		var created sqlTime
+		if err := rows.Scan(&item.SourceID.App, &instance, &tag, &item.Severity, &created); err != nil {
+			return nil, fmt.Errorf("failed to list report times: %w", err)
+		}
+		item.SourceID.Instance, item.SourceID.Tag = instance.String, tag.String
+		item.Created = created.Time
+		ans = append(ans, item)
+	}
+	return ans, nil
+}
+
 func (rdb *ReportsDatabase) GetOverview() ([]*general.ReportOverview, error) {
-	sql1 := "SELECT crg.app, crg.instance, crg.tag, crg.escalated, " +
+	sql1 := "SELECT crg.id, crg.app, crg.instance, crg.tag, crg.escalated, crg.escalation_level, crg.escalated_at, crg.deescalated, crg.reminders, crg.last_reminder, crg.acknowledged_by_user_id, ack.user, asg.user, crg.snoozed_until, " +
 		"SUM(CASE WHEN cr.severity = ? THEN 1 ELSE 0 END), " +
 		"SUM(CASE WHEN cr.severity = ? THEN 1 ELSE 0 END), " +
 		"SUM(CASE WHEN cr.severity = ? THEN 1 ELSE 0 END), " +
-		"SUM(CASE WHEN (cr.created > NOW() - INTERVAL 1 DAY) THEN 1 ELSE 0 END) AS recent, " +
+		"SUM(CASE WHEN (" + rdb.dialect.recentCond("cr.created") + ") THEN 1 ELSE 0 END) AS recent, " +
+		"MAX(CASE WHEN cr.severity IN (?, ?) AND cr.in_maintenance = ? THEN cr.created END), " +
 		"crg.created, MAX(cr.created) " +
 		"FROM conomi_report_group AS crg " +
 		"JOIN conomi_report AS cr ON crg.id = cr.report_group_id " +
+		"LEFT JOIN " + rdb.dialect.userTable() + " AS ack ON crg.acknowledged_by_user_id = ack.id " +
+		"LEFT JOIN " + rdb.dialect.userTable() + " AS asg ON crg.assigned_to_user_id = asg.id " +
 		"WHERE crg.resolved_by_user_id IS NULL " +
-		"GROUP BY crg.app, crg.instance, crg.tag ORDER BY recent DESC, crg.app, crg.instance, crg.tag"
+		"GROUP BY crg.id, crg.app, crg.instance, crg.tag, crg.escalated, crg.escalation_level, crg.escalated_at, crg.deescalated, crg.reminders, crg.last_reminder, crg.acknowledged_by_user_id, ack.user, asg.user, crg.snoozed_until, crg.created " +
+		"ORDER BY recent DESC, crg.app, crg.instance, crg.tag"
 	log.Debug().Str("sql", sql1).Msg("going to count conomi_report WHERE resolved_by_user_id IS NULL")
-	rows, err := rdb.db.Query(sql1, general.SeverityLevelCritical, general.SeverityLevelWarning, general.SeverityLevelInfo)
+	rows, err := rdb.query(
+		sql1,
+		general.SeverityLevelCritical, general.SeverityLevelWarning, general.SeverityLevelInfo,
+		general.SeverityLevelCritical, general.SeverityLevelWarning, false,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -217,12 +365,33 @@ func (rdb *ReportsDatabase) GetOverview() ([]*general.ReportOverview, error) {
 	ans := make([]*general.ReportOverview, 0, 100)
 	for rows.Next() {
 		count := &general.ReportOverview{}
-		var instance, tag sql.NullString
-		err := rows.Scan(&count.SourceID.App, &instance, &tag, &count.Escalated, &count.Critical, &count.Warning, &count.Info, &count.Recent, &count.Created, &count.Last)
+		var instance, tag, acknowledgedByUserName, assignedToUserName sql.NullString
+		var acknowledgedByUserID sql.NullInt32
+		var escalatedAt, deescalated, lastReminder, snoozedUntil, lastAlert, last sqlTime
+		err := rows.Scan(&count.GroupID, &count.SourceID.App, &instance, &tag, &count.Escalated, &count.EscalationLevel, &escalatedAt, &deescalated, &count.Reminders, &lastReminder, &acknowledgedByUserID, &acknowledgedByUserName, &assignedToUserName, &snoozedUntil, &count.Critical, &count.Warning, &count.Info, &count.Recent, &lastAlert, &count.Created, &last)
 		if err != nil {
 			return nil, err
 		}
 		count.SourceID.Instance, count.SourceID.Tag = instance.String, tag.String
+		count.Acknowledged = acknowledgedByUserID.Valid
+		count.AcknowledgedByUserName = acknowledgedByUserName.String
+		count.AssignedToUserName = assignedToUserName.String
+		if !snoozedUntil.Time.IsZero() {
+			count.SnoozedUntil = &snoozedUntil.Time
+		}
+		if !escalatedAt.Time.IsZero() {
+			count.EscalatedAt = &escalatedAt.Time
+		}
+		if !deescalated.Time.IsZero() {
+			count.Deescalated = &deescalated.Time
+		}
+		if !lastReminder.Time.IsZero() {
+			count.LastReminder = &lastReminder.Time
+		}
+		if !lastAlert.Time.IsZero() {
+			count.LastAlert = &lastAlert.Time
+		}
+		count.Last = last.Time
 		ans = append(ans, count)
 	}
 	return ans, nil
@@ -231,7 +400,7 @@ func (rdb *ReportsDatabase) GetOverview() ([]*general.ReportOverview, error) {
 func (rdb *ReportsDatabase) GetSources() ([]*general.SourceID, error) {
 	sql1 := "SELECT DISTINCT app, instance, tag FROM conomi_report_group WHERE resolved_by_user_id IS NULL ORDER BY app, instance, tag"
 	log.Debug().Str("sql", sql1).Msg("going to get available filters")
-	rows, err := rdb.db.Query(sql1)
+	rows, err := rdb.query(sql1)
 	if err != nil {
 		return nil, err
 	}
@@ -254,9 +423,9 @@ func (rdb *ReportsDatabase) GetSources() ([]*general.SourceID, error) {
 }
 
 func (rdb *ReportsDatabase) GetUserID(userName string) (int, error) {
-	sql1 := "SELECT id FROM user WHERE user = ? LIMIT 1"
+	sql1 := "SELECT us.id FROM " + rdb.dialect.userTable() + " AS us WHERE us.user = ? LIMIT 1"
 	log.Debug().Str("sql", sql1).Msg("going to get user id from name")
-	row := rdb.db.QueryRow(sql1, userName)
+	row := rdb.queryRow(sql1, userName)
 	var userID int
 	err := row.Scan(&userID)
 	if err == sql.ErrNoRows {
@@ -268,9 +437,38 @@ func (rdb *ReportsDatabase) GetUserID(userName string) (int, error) {
 	return userID, nil
 }
 
-func NewReportsDatabase(db *sql.DB) *ReportsDatabase {
+func (rdb *ReportsDatabase) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := rdb.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (rdb *ReportsDatabase) exec(query string, args ...any) (sql.Result, error) {
+	return rdb.db.Exec(rdb.dialect.rebind(query), args...)
+}
+
+func (rdb *ReportsDatabase) query(query string, args ...any) (*sql.Rows, error) {
+	return rdb.db.Query(rdb.dialect.rebind(query), args...)
+}
+
+func (rdb *ReportsDatabase) queryRow(query string, args ...any) *sql.Row {
+	return rdb.db.QueryRow(rdb.dialect.rebind(query), args...)
+}
+
+// Close closes the underlying database
+func (rdb *ReportsDatabase) Close() error {
+	return rdb.db.Close()
+}
+
+func NewReportsDatabase(db *sql.DB, dialect sqlDialect) *ReportsDatabase {
 	return &ReportsDatabase{
-		db:  db,
-		ctx: context.Background(),
+		db:      db,
+		dialect: dialect,
 	}
 }
@@ -0,0 +1,104 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+func TestListReportTimes(t *testing.T) {
+	rdb, userID := newTestStore(t)
+	app1 := general.SourceID{App: "app1"}
+	app2 := general.SourceID{App: "app2", Tag: "db"}
+	insertTestReports(
+		t, rdb, app1, 0,
+		general.SeverityLevelCritical, general.SeverityLevelInfo, general.SeverityLevelInfo, general.SeverityLevelInfo,
+	)
+	resolved := insertTestReports(t, rdb, app2, 1, general.SeverityLevelWarning)[0].GroupID
+	if err := rdb.ResolveGroup(resolved, userID); err != nil {
+		t.Fatal(err)
+	}
+	insertTestReports(t, rdb, app2, 2, general.SeverityLevelWarning)
+	maintenance := &general.Report{
+		SourceID:      app2,
+		Severity:      general.SeverityLevelCritical,
+		Subject:       "in maintenance",
+		Created:       testCreated.Add(3 * time.Hour),
+		InMaintenance: true,
+	}
+	if err := rdb.InsertReport(maintenance); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		from  time.Time
+		limit int
+		want  []time.Time
+	}{
+		{
+			"all",
+			testCreated,
+			10,
+			[]time.Time{
+				testCreated, testCreated.Add(time.Minute), testCreated.Add(2 * time.Minute),
+				testCreated.Add(3 * time.Minute), testCreated.Add(2 * time.Hour),
+			},
+		},
+		{
+			"limited per severity",
+			testCreated,
+			2,
+			[]time.Time{
+				testCreated, testCreated.Add(2 * time.Minute), testCreated.Add(3 * time.Minute),
+				testCreated.Add(2 * time.Hour),
+			},
+		},
+		{"since", testCreated.Add(time.Hour), 10, []time.Time{testCreated.Add(2 * time.Hour)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := rdb.ListReportTimes(tt.from, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]time.Time, len(items))
+			for i, item := range items {
+				got[i] = item.Created
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ListReportTimes() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("ListReportTimes() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+	items, err := rdb.ListReportTimes(testCreated.Add(time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items[0].SourceID != app2 || items[0].Severity != general.SeverityLevelWarning {
+		t.Errorf("unexpected item %+v", items[0])
+	}
+}
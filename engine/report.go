@@ -19,6 +19,7 @@ package engine
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/czcorpus/conomi/general"
@@ -39,6 +40,7 @@ type reportSQL struct {
 	ResolvedByUserID   sql.NullInt32
 	ResolvedByUserName sql.NullString
 	Escalated          bool
+	InMaintenance      bool
 }
 
 func (r *reportSQL) Export() (*general.Report, error) {
@@ -69,6 +71,7 @@ func (r *reportSQL) Export() (*general.Report, error) {
 		ResolvedByUserID:   resolvedByUserID,
 		ResolvedByUserName: r.ResolvedByUserName.String,
 		Escalated:          r.Escalated,
+		InMaintenance:      r.InMaintenance,
 	}, nil
 }
 
@@ -95,5 +98,48 @@ func NewReportSQL(r *general.Report) (*reportSQL, error) {
 		Created:          r.Created,
 		ResolvedByUserID: sql.NullInt32{Valid: r.ResolvedByUserID != -1, Int32: int32(r.ResolvedByUserID)},
 		Escalated:        r.Escalated,
+		InMaintenance:    r.InMaintenance,
 	}, nil
 }
+
+var sqlTimeFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// sqlTime is a datetime scanner accepting also textual values
+// (e.g. SQLite provides results of aggregate functions as strings)
+type sqlTime struct {
+	Time time.Time
+}
+
+func (st *sqlTime) parse(value string) error {
+	for _, format := range sqlTimeFormats {
+		if t, err := time.ParseInLocation(format, value, time.Local); err == nil {
+			st.Time = t.In(time.Local)
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to parse datetime value `%s`", value)
+}
+
+func (st *sqlTime) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		st.Time = time.Time{}
+		return nil
+	case time.Time:
+		st.Time = v
+		return nil
+	case string:
+		return st.parse(v)
+	case []byte:
+		return st.parse(string(v))
+	}
+	return fmt.Errorf("failed to scan datetime value of type %T", value)
+}
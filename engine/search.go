@@ -0,0 +1,257 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+)
+
+const maxTokenLength = 100
+
+var argKeyRegexp = regexp.MustCompile(`^[\p{L}\p{N}_-]+(\.[\p{L}\p{N}_-]+)*$`)
+
+// ArgFilter matches reports with a specified (possibly nested)
+// value in their JSON arguments
+type ArgFilter struct {
+	Path  []string
+	Value string
+}
+
+// SearchQuery is a parsed full-text query. Terms and phrases
+// are searched in report subjects and bodies.
+type SearchQuery struct {
+	Terms   []string
+	Phrases []string
+	Args    []ArgFilter
+}
+
+// ParseSearchQuery parses a query consisting of whitespace separated
+// terms, "quoted phrases" and `args.key:value` (or `args.key:"a value"`)
+// filters. Each of the returned terms and phrases contains at least one
+// word so the resulting search conditions never match everything.
+func ParseSearchQuery(query string) (*SearchQuery, error) {
+	ans := &SearchQuery{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+		if runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated phrase in search query")
+			}
+			if phrase := strings.TrimSpace(string(runes[i+1 : end])); len(tokenize(phrase)) > 0 {
+				ans.Phrases = append(ans.Phrases, phrase)
+			}
+			i = end + 1
+			continue
+		}
+		start := i
+		inQuotes := false
+		for i < len(runes) && (inQuotes || !unicode.IsSpace(runes[i])) {
+			if runes[i] == '"' {
+				inQuotes = !inQuotes
+			}
+			i++
+		}
+		if inQuotes {
+			return nil, fmt.Errorf("unterminated quoted value in search query")
+		}
+		item := string(runes[start:i])
+		if strings.HasPrefix(item, "args.") {
+			key, value, ok := strings.Cut(strings.TrimPrefix(item, "args."), ":")
+			if !ok {
+				return nil, fmt.Errorf("missing value in args filter `%s`", item)
+			}
+			if !argKeyRegexp.MatchString(key) {
+				return nil, fmt.Errorf("invalid key in args filter `%s`", item)
+			}
+			ans.Args = append(ans.Args, ArgFilter{
+				Path:  strings.Split(key, "."),
+				Value: strings.Trim(value, `"`),
+			})
+
+		} else if len(tokenize(item)) > 0 {
+			// terms without any letters or digits (e.g. `+++`) would
+			// be lost by search engines anyway so they are skipped
+			ans.Terms = append(ans.Terms, item)
+		}
+	}
+	if len(ans.Terms) == 0 && len(ans.Phrases) == 0 && len(ans.Args) == 0 {
+		return nil, fmt.Errorf("empty search query (no words to search for)")
+	}
+	return ans, nil
+}
+
+// tokenize splits a text into unique lowercase tokens
+// as stored in the fallback search index
+func tokenize(text string) []string {
+	ans := make([]string, 0, 50)
+	found := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if runes := []rune(word); len(runes) > maxTokenLength {
+			word = string(runes[:maxTokenLength])
+		}
+		if !found[word] {
+			found[word] = true
+			ans = append(ans, word)
+		}
+	}
+	return ans
+}
+
+// booleanModeQuery creates an expression for MySQL
+// `MATCH ... AGAINST (... IN BOOLEAN MODE)` requiring all
+// the terms and phrases
+func (sq *SearchQuery) booleanModeQuery() string {
+	clean := strings.NewReplacer(
+		"+", " ", "-", " ", "<", " ", ">", " ", "(", " ", ")", " ",
+		"~", " ", "*", " ", `"`, " ", "@", " ",
+	)
+	items := make([]string, 0, len(sq.Terms)+len(sq.Phrases))
+	for _, term := range sq.Terms {
+		for _, word := range strings.Fields(clean.Replace(term)) {
+			items = append(items, "+"+word)
+		}
+	}
+	for _, phrase := range sq.Phrases {
+		if words := strings.Fields(clean.Replace(phrase)); len(words) > 0 {
+			items = append(items, `+"`+strings.Join(words, " ")+`"`)
+		}
+	}
+	return strings.Join(items, " ")
+}
+
+// conditions provides WHERE conditions and respective values
+// matching the query
+func (sq *SearchQuery) conditions(dialect sqlDialect) ([]string, []any) {
+	whereParts := make([]string, 0, 10)
+	whereValues := make([]any, 0, 10)
+	if dialect.hasFullText() {
+		if expr := sq.booleanModeQuery(); expr != "" {
+			whereParts = append(whereParts, "MATCH (cr.subject, cr.body) AGAINST (? IN BOOLEAN MODE)")
+			whereValues = append(whereValues, expr)
+		}
+
+	} else {
+		tokens := make([]string, 0, 10)
+		for _, term := range sq.Terms {
+			tokens = append(tokens, tokenize(term)...)
+		}
+		for _, phrase := range sq.Phrases {
+			tokens = append(tokens, tokenize(phrase)...)
+			// the index knows nothing about word order
+			// so we must verify the phrases
+			whereParts = append(
+				whereParts,
+				"(LOWER(cr.subject) LIKE ? ESCAPE '!' OR LOWER(cr.body) LIKE ? ESCAPE '!')",
+			)
+			pattern := "%" + escapeLike(strings.ToLower(phrase)) + "%"
+			whereValues = append(whereValues, pattern, pattern)
+		}
+		for _, token := range tokens {
+			whereParts = append(
+				whereParts,
+				"cr.id IN (SELECT report_id FROM conomi_report_token WHERE token = ?)",
+			)
+			whereValues = append(whereValues, token)
+		}
+	}
+	for _, arg := range sq.Args {
+		expr, path := dialect.jsonValue("cr.args", arg.Path)
+		whereParts = append(whereParts, expr+" = ?")
+		whereValues = append(whereValues, path, arg.Value)
+	}
+	return whereParts, whereValues
+}
+
+// indexReport stores tokens of report texts in the fallback search index
+func indexReport(db sqlExecutor, dialect sqlDialect, reportID int, texts ...string) error {
+	tokens := tokenize(strings.Join(texts, "\n"))
+	for len(tokens) > 0 {
+		chunk := tokens[:min(len(tokens), 100)]
+		tokens = tokens[len(chunk):]
+		values := make([]any, 0, 2*len(chunk))
+		for _, token := range chunk {
+			values = append(values, reportID, token)
+		}
+		sql1 := "INSERT INTO conomi_report_token (report_id, token) VALUES " +
+			strings.TrimSuffix(strings.Repeat("(?,?),", len(chunk)), ",")
+		if _, err := db.Exec(dialect.rebind(sql1), values...); err != nil {
+			return fmt.Errorf("failed to index report: %w", err)
+		}
+	}
+	return nil
+}
+
+// backfillSearchIndex indexes all the stored reports when
+// the fallback search index is created. Engines with built-in
+// full-text search need nothing.
+func backfillSearchIndex(tx *sql.Tx, dialect sqlDialect) error {
+	if dialect.hasFullText() {
+		return nil
+	}
+	type reportText struct {
+		id            int
+		subject, body string
+	}
+	lastID := 0
+	for {
+		sql1 := "SELECT id, subject, body FROM conomi_report WHERE id > ? ORDER BY id LIMIT 1000"
+		log.Debug().Str("sql", sql1).Msg("going to SELECT conomi_report for indexing")
+		rows, err := tx.Query(dialect.rebind(sql1), lastID)
+		if err != nil {
+			return fmt.Errorf("failed to backfill search index: %w", err)
+		}
+		batch := make([]reportText, 0, 1000)
+		for rows.Next() {
+			var item reportText
+			if err := rows.Scan(&item.id, &item.subject, &item.body); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to backfill search index: %w", err)
+			}
+			batch = append(batch, item)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to backfill search index: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		for _, item := range batch {
+			if err := indexReport(tx, dialect, item.id, item.subject, item.body); err != nil {
+				return fmt.Errorf("failed to backfill search index: %w", err)
+			}
+		}
+		lastID = batch[len(batch)-1].id
+	}
+}
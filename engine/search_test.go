@@ -0,0 +1,185 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    *SearchQuery
+		wantErr bool
+	}{
+		{"terms", "disk  full", &SearchQuery{Terms: []string{"disk", "full"}}, false},
+		{"phrase", `"disk full" error`, &SearchQuery{Terms: []string{"error"}, Phrases: []string{"disk full"}}, false},
+		{
+			"args",
+			`args.host:srv1 args.job.name:"nightly backup"`,
+			&SearchQuery{Args: []ArgFilter{
+				{Path: []string{"host"}, Value: "srv1"},
+				{Path: []string{"job", "name"}, Value: "nightly backup"},
+			}},
+			false,
+		},
+		{"punctuation only terms", "+++ -- error", &SearchQuery{Terms: []string{"error"}}, false},
+		{"punctuation only phrase", `"()" error`, &SearchQuery{Terms: []string{"error"}}, false},
+		{"nothing to search for", `+++ "--" *`, nil, true},
+		{"empty", "   ", nil, true},
+		{"unterminated phrase", `"disk full`, nil, true},
+		{"unterminated value", `args.host:"srv1`, nil, true},
+		{"missing value", "args.host", nil, true},
+		{"invalid key", "args.a..b:1", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSearchQuery(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSearchQuery(%q) error = %v, wantErr %t", tt.query, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSearchQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBooleanModeQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query SearchQuery
+		want  string
+	}{
+		{"terms", SearchQuery{Terms: []string{"disk", "full"}}, "+disk +full"},
+		{"operators", SearchQuery{Terms: []string{"-disk*", "(full)"}}, "+disk +full"},
+		{"phrase", SearchQuery{Phrases: []string{`disk "full"`}}, `+"disk full"`},
+		{"args only", SearchQuery{Args: []ArgFilter{{Path: []string{"a"}, Value: "1"}}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.booleanModeQuery(); got != tt.want {
+				t.Errorf("booleanModeQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("Disk /dev/sda1 is FULL, disk full!")
+	want := []string{"disk", "dev", "sda1", "is", "full"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenize() = %q, want %q", got, want)
+	}
+}
+
+func searchReports(t *testing.T, rdb *ReportsDatabase, query string) []string {
+	t.Helper()
+	search, err := ParseSearchQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports, err := rdb.ListReports(ListReportsArgs{Search: search, Order: SortOrderAsc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ans := make([]string, len(reports))
+	for i, report := range reports {
+		ans[i] = report.Subject
+	}
+	return ans
+}
+
+func TestSearchIndex(t *testing.T) {
+	db, dialect := openTestDB(t)
+	rdb := NewReportsDatabase(db, dialect)
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, subject := range []string{"Disk full", "Backup failed", "Disk almost full"} {
+		err := rdb.InsertReport(&general.Report{
+			SourceID: general.SourceID{App: "test"},
+			Severity: general.SeverityLevelWarning,
+			Subject:  subject,
+			Body:     "see logs",
+			Created:  created.Add(time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"Disk full", "Disk almost full"}
+	if got := searchReports(t, rdb, "disk full"); !reflect.DeepEqual(got, want) {
+		t.Errorf("search for terms = %q, want %q", got, want)
+	}
+	if got := searchReports(t, rdb, `"disk full"`); !reflect.DeepEqual(got, want[:1]) {
+		t.Errorf("search for phrase = %q, want %q", got, want[:1])
+	}
+
+	// reports inserted before the index existed are indexed by the migration
+	if _, err := db.Exec("DELETE FROM conomi_report_token"); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchReports(t, rdb, "disk"); len(got) != 0 {
+		t.Fatalf("expected no results with an empty index, got %q", got)
+	}
+	err := rdb.withTx(func(tx *sql.Tx) error {
+		return backfillSearchIndex(tx, dialect)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := searchReports(t, rdb, "disk full"); !reflect.DeepEqual(got, want) {
+		t.Errorf("search after backfill = %q, want %q", got, want)
+	}
+}
+
+func TestSearchPhraseNonASCII(t *testing.T) {
+	db, dialect := openTestDB(t)
+	rdb := NewReportsDatabase(db, dialect)
+	for i, subject := range []string{"ŠIFROVÁNÍ SELHALO", "Šifrování dokončeno", "Selhalo šifrování"} {
+		err := rdb.InsertReport(&general.Report{
+			SourceID: general.SourceID{App: "test"},
+			Severity: general.SeverityLevelWarning,
+			Subject:  subject,
+			Body:     "Čeká se na ŘEŠENÍ",
+			Created:  testCreated.Add(time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{`"šifrování selhalo"`, []string{"ŠIFROVÁNÍ SELHALO"}},
+		{`"Šifrování Dokončeno"`, []string{"Šifrování dokončeno"}},
+		{`"čeká se na řešení"`, []string{"ŠIFROVÁNÍ SELHALO", "Šifrování dokončeno", "Selhalo šifrování"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := searchReports(t, rdb, tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("search for %s = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/rs/zerolog/log"
+)
+
+// SnoozeGroup silences notifications of an unresolved group until
+// the `until` time. Zero `until` cancels the snooze.
+func (rdb *ReportsDatabase) SnoozeGroup(groupID int, until time.Time, userID int) error {
+	var snoozedUntil any
+	event := &general.GroupEvent{Type: general.GroupEventSnoozed, UserID: userID, Body: "Snooze cancelled"}
+	if !until.IsZero() {
+		snoozedUntil = until
+		event.Body = "Snoozed until " + until.Format(time.RFC3339)
+	}
+	updated, err := rdb.updateOpenGroup(groupID, "snoozed_until = ?", event, snoozedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to snooze group: %w", err)
+	}
+	if !updated {
+		return fmt.Errorf(
+			"failed to snooze group: %w: group %d not found or already resolved",
+			ErrInvalidGroupOperation, groupID)
+	}
+	return nil
+}
+
+// SnoozeSource silences notifications of a source until the `until` time.
+// An existing snooze of the same source is replaced.
+func (rdb *ReportsDatabase) SnoozeSource(sourceID general.SourceID, until time.Time, userID int) error {
+	err := rdb.withTx(func(tx *sql.Tx) error {
+		sql1 := "DELETE FROM conomi_source_snooze " +
+			"WHERE (app = ? AND instance = ? AND tag = ?) OR " + rdb.dialect.timeCmp("snoozed_until", "<")
+		log.Debug().Str("sql", sql1).Msg("going to DELETE replaced and expired conomi_source_snooze")
+		_, err := tx.Exec(rdb.dialect.rebind(sql1), sourceID.App, sourceID.Instance, sourceID.Tag, time.Now())
+		if err != nil {
+			return err
+		}
+		sql1 = "INSERT INTO conomi_source_snooze (app, instance, tag, snoozed_until, user_id, created) VALUES (?,?,?,?,?,?)"
+		log.Debug().Str("sql", sql1).Msg("going to INSERT conomi_source_snooze")
+		_, err = tx.Exec(
+			rdb.dialect.rebind(sql1),
+			sourceID.App, sourceID.Instance, sourceID.Tag, until,
+			sql.NullInt32{Int32: int32(userID), Valid: userID >= 0}, time.Now(),
+		)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to snooze source: %w", err)
+	}
+	return nil
+}
+
+// UnsnoozeSource cancels a snooze of the source
+func (rdb *ReportsDatabase) UnsnoozeSource(sourceID general.SourceID) error {
+	sql1 := "DELETE FROM conomi_source_snooze WHERE app = ? AND instance = ? AND tag = ?"
+	log.Debug().Str("sql", sql1).Msg("going to DELETE conomi_source_snooze")
+	if _, err := rdb.exec(sql1, sourceID.App, sourceID.Instance, sourceID.Tag); err != nil {
+		return fmt.Errorf("failed to unsnooze source: %w", err)
+	}
+	return nil
+}
+
+// ListSourceSnoozes provides all the source snoozes which have not expired yet
+func (rdb *ReportsDatabase) ListSourceSnoozes() ([]*general.SourceSnooze, error) {
+	sql1 := "SELECT ss.app, ss.instance, ss.tag, ss.snoozed_until, ss.user_id, us.user, ss.created " +
+		"FROM conomi_source_snooze AS ss " +
+		"LEFT JOIN " + rdb.dialect.userTable() + " AS us ON ss.user_id = us.id " +
+		"WHERE " + rdb.dialect.timeCmp("ss.snoozed_until", ">=") + " " +
+		"ORDER BY ss.app, ss.instance, ss.tag"
+	log.Debug().Str("sql", sql1).Msg("going to SELECT conomi_source_snooze")
+	rows, err := rdb.query(sql1, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source snoozes: %w", err)
+	}
+	defer rows.Close()
+	ans := make([]*general.SourceSnooze, 0, 10)
+	for rows.Next() {
+		snooze := &general.SourceSnooze{UserID: -1}
+		var userID sql.NullInt32
+		var userName sql.NullString
+		var snoozedUntil, created sqlTime
+		err := rows.Scan(
+			&snooze.SourceID.App, &snooze.SourceID.Instance, &snooze.SourceID.Tag,
+			&snoozedUntil, &userID, &userName, &created,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list source snoozes: %w", err)
+		}
+		if userID.Valid {
+			snooze.UserID = int(userID.Int32)
+		}
+		snooze.UserName = userName.String
+		snooze.SnoozedUntil, snooze.Created = snoozedUntil.Time, created.Time
+		ans = append(ans, snooze)
+	}
+	return ans, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"database/sql"
+	"net/url"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const sqliteDriverName = "sqlite3_conomi"
+
+func init() {
+	// the built-in LOWER function folds ASCII letters only so it is
+	// replaced to make case-insensitive matching work for any text
+	sql.Register(sqliteDriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("lower", strings.ToLower, true)
+		},
+	})
+}
+
+type sqliteDialect struct{}
+
+func (d sqliteDialect) migrationsDir() string {
+	return DriverSQLite
+}
+
+func (d sqliteDialect) schemaVersionTable() string {
+	return `CREATE TABLE IF NOT EXISTS conomi_schema_version (
+		version INTEGER PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		applied datetime NOT NULL
+	)`
+}
+
+func (d sqliteDialect) rebind(query string) string {
+	return query
+}
+
+func (d sqliteDialect) insert(db sqlExecutor, query string, args ...any) (int64, error) {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (d sqliteDialect) userTable() string {
+	return "user"
+}
+
+func (d sqliteDialect) timeCmp(column, op string) string {
+	return "julianday(" + column + ") " + op + " julianday(?)"
+}
+
+func (d sqliteDialect) hasFullText() bool {
+	return false
+}
+
+func (d sqliteDialect) jsonValue(column string, path []string) (string, any) {
+	return "CAST(json_extract(" + column + ", ?) AS TEXT)", jsonPath(path)
+}
+
+func (d sqliteDialect) recentCond(column string) string {
+	// julianday() also normalizes values stored with a time zone offset
+	return "julianday(" + column + ") > julianday('now', '-1 day')"
+}
+
+func (d sqliteDialect) transactionalDDL() bool {
+	return true
+}
+
+func (d sqliteDialect) isAppliedSchemaChange(err error) bool {
+	return false
+}
+
+func openSQLite(conf *DBConf) (*sql.DB, error) {
+	params := url.Values{}
+	params.Set("_loc", "auto")
+	params.Set("_busy_timeout", "5000")
+	params.Set("_foreign_keys", "1")
+	db, err := sql.Open(sqliteDriverName, "file:"+conf.Name+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows just a single writer anyway
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
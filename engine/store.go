@@ -0,0 +1,172 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+const (
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+type DBConf struct {
+	// Driver specifies the storage backend (`mysql`, `postgres` or `sqlite`)
+	Driver string `json:"driver"`
+
+	// Host may also contain a port in case of `mysql`
+	// (if Port is not specified)
+	Host string `json:"host"`
+	Port int    `json:"port"`
+
+	// Name is a database name (or a path to the database file
+	// in case of `sqlite`)
+	Name     string `json:"name"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	PoolSize int    `json:"poolSize"`
+
+	// SSLMode is applied only in case of `postgres`
+	// (for possible values see the `sslmode` connection parameter)
+	SSLMode string `json:"sslMode"`
+}
+
+// ReportTime is a lightweight record of a report used
+// for time window based evaluation of reports
+type ReportTime struct {
+	SourceID general.SourceID
+	Severity general.SeverityLevel
+	Created  time.Time
+}
+
+// ReportStore represents a storage of reports and report groups
+type ReportStore interface {
+	InsertReport(report *general.Report) error
+	ListReports(args ListReportsArgs) ([]*general.Report, error)
+	CountReports(args ListReportsArgs) (int, error)
+	SelectReport(reportID int) (*general.Report, error)
+	ResolveGroup(groupID int, userID int) error
+	EscalateGroup(groupID int) error
+	DeescalateGroup(groupID int) error
+	PromoteGroup(groupID int, level int) error
+	RecordReminder(groupID int, reminder int) error
+	ListReportTimes(from time.Time, limit int) ([]*ReportTime, error)
+	GetOverview() ([]*general.ReportOverview, error)
+	GetSources() ([]*general.SourceID, error)
+	GetUserID(userName string) (int, error)
+
+	ListGroups(args ListGroupsArgs) ([]*general.ReportGroup, error)
+	CountGroups(args ListGroupsArgs) (int, error)
+	SelectGroup(groupID int) (*general.ReportGroup, error)
+
+	ReopenGroup(groupID int, userID int) error
+	AcknowledgeGroup(groupID int, userID int) error
+	AssignGroup(groupID int, assigneeID int, userID int) error
+	MergeGroups(targetID int, groupIDs []int, userID int) error
+	SplitGroup(groupID int, reportIDs []int, userID int) (int, error)
+
+	SnoozeGroup(groupID int, until time.Time, userID int) error
+	SnoozeSource(sourceID general.SourceID, until time.Time, userID int) error
+	UnsnoozeSource(sourceID general.SourceID) error
+	ListSourceSnoozes() ([]*general.SourceSnooze, error)
+
+	InsertMaintenanceWindow(window *general.MaintenanceWindow) error
+	DeleteMaintenanceWindow(windowID int) error
+	ListMaintenanceWindows() ([]*general.MaintenanceWindow, error)
+
+	AddGroupEvent(event *general.GroupEvent) error
+	ListGroupEvents(groupID int) ([]*general.GroupEvent, error)
+
+	// FindExpiredReports provides IDs of reports of a specified
+	// severity created before `olderThan`
+	FindExpiredReports(severity general.SeverityLevel, olderThan time.Time, onlyResolved bool, offset, limit int) ([]int, error)
+
+	// FindOversizedGroups provides IDs of groups with more than `maxReports` reports
+	FindOversizedGroups(maxReports int, onlyResolved bool) ([]int, error)
+
+	// FindExcessReports provides IDs of group reports exceeding
+	// the `keep` newest ones
+	FindExcessReports(groupID int, keep int, offset, limit int) ([]int, error)
+
+	// DeleteReports removes reports (optionally moving them
+	// to the archive table first)
+	DeleteReports(reportIDs []int, archive bool) error
+
+	// DeleteEmptyGroups removes resolved groups without any reports
+	DeleteEmptyGroups() (int, error)
+
+	// CountEmptiedGroups provides the number of groups which would be
+	// removed by DeleteEmptyGroups once reports `reportIDs` are deleted
+	CountEmptiedGroups(reportIDs []int) (int, error)
+
+	// Close closes the underlying database
+	Close() error
+}
+
+func openDB(conf *DBConf) (*sql.DB, sqlDialect, error) {
+	var db *sql.DB
+	var dialect sqlDialect
+	var err error
+	switch conf.Driver {
+	case DriverMySQL:
+		db, err = openMySQL(conf)
+		dialect = mysqlDialect{}
+	case DriverPostgres:
+		db, err = openPostgres(conf)
+		dialect = postgresDialect{}
+	case DriverSQLite:
+		db, err = openSQLite(conf)
+		dialect = sqliteDialect{}
+	default:
+		err = fmt.Errorf("unknown database driver `%s`", conf.Driver)
+	}
+	return db, dialect, err
+}
+
+// Open creates a report store based on the configured driver.
+// The function refuses to work with a database with an outdated
+// (or unknown) schema version.
+func Open(conf *DBConf) (ReportStore, error) {
+	db, dialect, err := openDB(conf)
+	if err != nil {
+		return nil, err
+	}
+	migrator, err := newMigrator(db, dialect)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	status, err := migrator.Status()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if status.Current != status.Latest {
+		db.Close()
+		return nil, fmt.Errorf(
+			"database schema version is %d, required %d (use the `migrate` action)",
+			status.Current, status.Latest,
+		)
+	}
+	return NewReportsDatabase(db, dialect), nil
+}
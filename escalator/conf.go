@@ -0,0 +1,284 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package escalator
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+const (
+	dfltBufferSize        = 1000
+	dfltRateWindowSecs    = 300
+	dfltCheckIntervalSecs = 60
+)
+
+// dfltPolicy reflects the original behaviour - any critical report
+// escalates as well as more than ten warnings
+var dfltPolicy = PolicyConf{
+	Thresholds: map[general.SeverityLevel]int{
+		general.SeverityLevelCritical: 1,
+		general.SeverityLevelWarning:  11,
+	},
+}
+
+// PolicyConf specifies when reports of matching sources escalate.
+// Empty App, Instance or Tag match any value.
+type PolicyConf struct {
+	App      string `json:"app"`
+	Instance string `json:"instance"`
+	Tag      string `json:"tag"`
+
+	// Thresholds specifies for each severity level the number of reports
+	// needed to escalate. Severities not listed here never escalate.
+	Thresholds map[general.SeverityLevel]int `json:"thresholds"`
+
+	// WindowSecs makes the thresholds apply to a sliding time window
+	// instead of the whole lifetime of a group. Zero means no window.
+	WindowSecs int `json:"windowSecs"`
+
+	// Rates specifies for each severity level the rate (reports per minute)
+	// needed to escalate. The rate is measured within WindowSecs
+	// (or within the global rate window if WindowSecs is zero).
+	Rates map[general.SeverityLevel]float64 `json:"rates"`
+
+	// Exempt sources never escalate
+	Exempt bool `json:"exempt"`
+
+	// DeescalateAfterMins specifies how long an escalated source must be
+	// quiet (no critical or warning reports) to be de-escalated
+	// automatically. Zero disables the de-escalation.
+	DeescalateAfterMins int `json:"deescalateAfterMins"`
+}
+
+func (pc *PolicyConf) matches(sourceID general.SourceID) bool {
+	return (pc.App == "" || pc.App == sourceID.App) &&
+		(pc.Instance == "" || pc.Instance == sourceID.Instance) &&
+		(pc.Tag == "" || pc.Tag == sourceID.Tag)
+}
+
+func (pc *PolicyConf) window() time.Duration {
+	return time.Duration(pc.WindowSecs) * time.Second
+}
+
+func (pc *PolicyConf) quietPeriod() time.Duration {
+	return time.Duration(pc.DeescalateAfterMins) * time.Minute
+}
+
+func (pc *PolicyConf) validate(bufferSize int, rateWindow time.Duration) error {
+	for severity, threshold := range pc.Thresholds {
+		if err := severity.Validate(); err != nil {
+			return err
+		}
+		if threshold <= 0 {
+			return fmt.Errorf("threshold of %s must be positive", severity)
+		}
+		// after a de-escalation, reports are counted using the buffer
+		if (pc.WindowSecs > 0 || pc.DeescalateAfterMins > 0) && threshold > bufferSize {
+			return fmt.Errorf("threshold of %s cannot exceed bufferSize %d", severity, bufferSize)
+		}
+	}
+	if pc.WindowSecs > 0 {
+		rateWindow = pc.window()
+	}
+	for severity, rate := range pc.Rates {
+		if err := severity.Validate(); err != nil {
+			return err
+		}
+		if rate <= 0 {
+			return fmt.Errorf("rate of %s must be positive", severity)
+		}
+		if rate*rateWindow.Minutes() > float64(bufferSize) {
+			return fmt.Errorf("rate of %s cannot be reached with bufferSize %d", severity, bufferSize)
+		}
+	}
+	if pc.WindowSecs < 0 {
+		return fmt.Errorf("windowSecs cannot be negative")
+	}
+	if pc.DeescalateAfterMins < 0 {
+		return fmt.Errorf("deescalateAfterMins cannot be negative")
+	}
+	return nil
+}
+
+// TierConf specifies a level of an escalation chain
+type TierConf struct {
+	// AfterMins specifies how long a group must stay escalated and
+	// unacknowledged to reach the tier. The first tier is reached
+	// right after the escalation so it should be zero.
+	AfterMins int `json:"afterMins"`
+
+	// Notifiers lists names of notifiers the escalation
+	// message is sent to once the tier is reached
+	Notifiers []string `json:"notifiers"`
+}
+
+func (tc *TierConf) after() time.Duration {
+	return time.Duration(tc.AfterMins) * time.Minute
+}
+
+// ReminderConf specifies re-sending of escalation messages
+// for groups which remain unacknowledged
+type ReminderConf struct {
+	// IntervalMins specifies the time between reminders
+	IntervalMins int `json:"intervalMins"`
+
+	// MaxCount limits the number of reminders sent for a single
+	// escalation (zero means no limit)
+	MaxCount int `json:"maxCount"`
+}
+
+func (rc *ReminderConf) interval() time.Duration {
+	return time.Duration(rc.IntervalMins) * time.Minute
+}
+
+type Conf struct {
+	// Policies are evaluated in the order of their definition,
+	// the first matching one is applied
+	Policies []PolicyConf `json:"policies"`
+
+	// Default policy is applied in case no other policy matches
+	Default *PolicyConf `json:"default"`
+
+	// BufferSize specifies how many latest reports are tracked
+	// per source and severity for time window based evaluation
+	BufferSize int `json:"bufferSize"`
+
+	// RateWindowSecs specifies the time window used to measure
+	// the current rate of reports
+	RateWindowSecs int `json:"rateWindowSecs"`
+
+	// Tiers specify an escalation chain. Escalated groups are promoted
+	// to higher tiers over time unless they get acknowledged. If empty,
+	// escalation messages are sent via the assignee's personal notifiers
+	// or via all the shared ones.
+	Tiers []TierConf `json:"tiers"`
+
+	// Reminders specify re-sending of escalation messages
+	// (nil means no reminders)
+	Reminders *ReminderConf `json:"reminders"`
+
+	// CheckIntervalSecs specifies how often escalated sources are
+	// checked for possible de-escalation, promotion or reminders
+	CheckIntervalSecs int `json:"checkIntervalSecs"`
+}
+
+func (conf *Conf) ValidateAndDefaults() error {
+	if conf.BufferSize == 0 {
+		conf.BufferSize = dfltBufferSize
+	}
+	if conf.BufferSize < 0 {
+		return fmt.Errorf("invalid escalation conf: bufferSize cannot be negative")
+	}
+	if conf.RateWindowSecs == 0 {
+		conf.RateWindowSecs = dfltRateWindowSecs
+	}
+	if conf.RateWindowSecs < 0 {
+		return fmt.Errorf("invalid escalation conf: rateWindowSecs cannot be negative")
+	}
+	if conf.CheckIntervalSecs == 0 {
+		conf.CheckIntervalSecs = dfltCheckIntervalSecs
+	}
+	if conf.CheckIntervalSecs < 0 {
+		return fmt.Errorf("invalid escalation conf: checkIntervalSecs cannot be negative")
+	}
+	for i, tier := range conf.Tiers {
+		if len(tier.Notifiers) == 0 {
+			return fmt.Errorf("invalid escalation tier %d: no notifiers specified", i+1)
+		}
+		if i > 0 && tier.AfterMins <= conf.Tiers[i-1].AfterMins {
+			return fmt.Errorf("invalid escalation tier %d: afterMins must be greater than in the previous tier", i+1)
+		}
+		if tier.AfterMins < 0 {
+			return fmt.Errorf("invalid escalation tier %d: afterMins cannot be negative", i+1)
+		}
+	}
+	if conf.Reminders != nil {
+		if conf.Reminders.IntervalMins <= 0 {
+			return fmt.Errorf("invalid escalation conf: reminders intervalMins must be positive")
+		}
+		if conf.Reminders.MaxCount < 0 {
+			return fmt.Errorf("invalid escalation conf: reminders maxCount cannot be negative")
+		}
+	}
+	for i, policy := range conf.Policies {
+		if err := policy.validate(conf.BufferSize, conf.rateWindow()); err != nil {
+			return fmt.Errorf("invalid escalation policy %d: %w", i, err)
+		}
+	}
+	if conf.Default == nil {
+		conf.Default = &dfltPolicy
+		return nil
+	}
+	if err := conf.Default.validate(conf.BufferSize, conf.rateWindow()); err != nil {
+		return fmt.Errorf("invalid default escalation policy: %w", err)
+	}
+	return nil
+}
+
+// tierNotifiers provides names of notifiers of all the tiers
+// up to the specified escalation level
+func (conf *Conf) tierNotifiers(level int) []string {
+	ans := make([]string, 0, 10)
+	for i := 0; i < level && i < len(conf.Tiers); i++ {
+		for _, name := range conf.Tiers[i].Notifiers {
+			if !slices.Contains(ans, name) {
+				ans = append(ans, name)
+			}
+		}
+	}
+	return ans
+}
+
+func (conf *Conf) checkInterval() time.Duration {
+	return time.Duration(conf.CheckIntervalSecs) * time.Second
+}
+
+func (conf *Conf) policy(sourceID general.SourceID) *PolicyConf {
+	for i := range conf.Policies {
+		if conf.Policies[i].matches(sourceID) {
+			return &conf.Policies[i]
+		}
+	}
+	return conf.Default
+}
+
+func (conf *Conf) rateWindow() time.Duration {
+	return time.Duration(conf.RateWindowSecs) * time.Second
+}
+
+// policyRateWindow provides the time window used to measure
+// rates of the policy
+func (conf *Conf) policyRateWindow(policy *PolicyConf) time.Duration {
+	if policy.WindowSecs > 0 {
+		return policy.window()
+	}
+	return conf.rateWindow()
+}
+
+// maxWindow provides the longest time window of all the policies
+// (including the rate window)
+func (conf *Conf) maxWindow() time.Duration {
+	ans := max(conf.rateWindow(), conf.Default.window())
+	for _, policy := range conf.Policies {
+		ans = max(ans, policy.window())
+	}
+	return ans
+}
@@ -17,20 +17,82 @@
 package escalator
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/czcorpus/conomi/engine"
 	"github.com/czcorpus/conomi/general"
 	"github.com/czcorpus/conomi/notifiers"
+	"github.com/rs/zerolog/log"
 )
 
-const escalateWarningCount = 10
+// notification is an escalation message waiting to be sent. Messages
+// are sent once the escalator state is unlocked as notifiers may ask
+// the escalator back (see IsSnoozed).
+type notification struct {
+	assignee  string
+	notifiers []string
+	message   *general.Report
 
+	// record stores the fact the message has been sent
+	record func() error
+}
+
+// stateChange is a change of the escalator state to be stored. The state
+// is changed in advance (so concurrent evaluations do not repeat it)
+// and the change is reverted if it cannot be stored.
+type stateChange struct {
+	// update stores the change (nil if there is nothing to store)
+	update func() error
+
+	// revert is called with the state locked
+	revert func()
+
+	// messages are sent once the change is stored
+	messages []notification
+}
+
+// Maintenance tells whether a source is under maintenance
+type Maintenance interface {
+	IsActive(sourceID general.SourceID, t time.Time) bool
+}
+
+// Escalator tracks reports of unresolved groups and escalates them.
+// It is safe for concurrent use.
 type Escalator struct {
-	counts    map[string]*general.ReportOverview
-	db        *sql.DB
-	notifiers *notifiers.Notifiers
+	conf *Conf
+
+	// ingest makes storing a report along with its evaluation a single
+	// step with respect to Reload (the same applies to periodic checks)
+	ingest sync.RWMutex
+
+	// updates keeps store updates of state changes in the order
+	// the changes have been made
+	updates sync.Mutex
+
+	// mu guards counts, snoozes and events. It is never held during
+	// store operations.
+	mu sync.RWMutex
+
+	counts      map[string]*general.ReportOverview
+	snoozes     []*general.SourceSnooze
+	store       engine.ReportStore
+	notifiers   *notifiers.Notifiers
+	maintenance Maintenance
+
+	// events contains latest reports of each source
+	// for time window and rate based evaluation
+	events map[string]sourceEvents
+
+	done chan struct{}
+}
+
+// SetMaintenance makes the periodic checks aware of maintenance
+// windows. It must be called before Start.
+func (e *Escalator) SetMaintenance(maintenance Maintenance) {
+	e.maintenance = maintenance
 }
 
 func (e *Escalator) makeKey(sourceID general.SourceID) string {
@@ -38,15 +100,183 @@ func (e *Escalator) makeKey(sourceID general.SourceID) string {
 }
 
 func (e *Escalator) Set(count *general.ReportOverview) {
-	key := e.makeKey(count.SourceID)
-	e.counts[key] = count
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.counts[e.makeKey(count.SourceID)] = count
+}
+
+// Recipients provides a name of the user the current incident
+// of the source is assigned to (empty string if none) along with
+// notifiers of the escalation chain up to the current level
+// (nil if there is no chain configured)
+func (e *Escalator) Recipients(sourceID general.SourceID) (string, []string) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	count, ok := e.counts[e.makeKey(sourceID)]
+	if !ok {
+		return "", e.tierNotifiers(1)
+	}
+	return count.AssignedToUserName, e.tierNotifiers(max(count.EscalationLevel, 1))
+}
+
+// IsSnoozed tells whether notifications for the report should be
+// skipped because its group or source is snoozed
+func (e *Escalator) IsSnoozed(report *general.Report) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.isSnoozed(report)
+}
+
+func (e *Escalator) isSnoozed(report *general.Report) bool {
+	now := time.Now()
+	if count, ok := e.counts[e.makeKey(report.SourceID)]; ok {
+		if count.SnoozedUntil != nil && count.SnoozedUntil.After(now) {
+			return true
+		}
+	}
+	for _, snooze := range e.snoozes {
+		if snooze.Matches(report.SourceID) && snooze.SnoozedUntil.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSilenced tells whether promotions and reminders of the source
+// should be postponed (i.e. it is snoozed or under maintenance)
+func (e *Escalator) isSilenced(sourceID general.SourceID, now time.Time) bool {
+	if e.isSnoozed(&general.Report{SourceID: sourceID}) {
+		return true
+	}
+	return e.maintenance != nil && e.maintenance.IsActive(sourceID, now)
+}
+
+// addEvent registers a report for time window and rate based evaluation
+func (e *Escalator) addEvent(key string, severity general.SeverityLevel, created time.Time) {
+	events, ok := e.events[key]
+	if !ok {
+		events = make(sourceEvents)
+		e.events[key] = events
+	}
+	events.add(created, severity, e.conf.BufferSize)
+}
+
+// Rate provides the current rate (reports per minute) of the source
+func (e *Escalator) Rate(sourceID general.SourceID) float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	events, ok := e.events[e.makeKey(sourceID)]
+	if !ok {
+		return 0
+	}
+	return events.rate(time.Now(), e.conf.rateWindow(), "")
+}
+
+// numReports provides number of reports of a severity
+// relevant for the policy
+func (e *Escalator) numReports(
+	key string,
+	count *general.ReportOverview,
+	policy *PolicyConf,
+	severity general.SeverityLevel,
+	now time.Time,
+) int {
+	if policy.WindowSecs == 0 && count.Deescalated == nil {
+		switch severity {
+		case general.SeverityLevelCritical:
+			return count.Critical
+		case general.SeverityLevelWarning:
+			return count.Warning
+		case general.SeverityLevelInfo:
+			return count.Info
+		}
+		return 0
+	}
+	events, ok := e.events[key]
+	if !ok {
+		return 0
+	}
+	if policy.WindowSecs == 0 {
+		// a de-escalated source starts counting from scratch
+		return events.count(*count.Deescalated, severity)
+	}
+	since := now.Add(-policy.window())
+	if count.Deescalated != nil && count.Deescalated.After(since) {
+		since = *count.Deescalated
+	}
+	return events.count(since, severity)
+}
+
+// shouldEscalate evaluates escalation policy of the source
+func (e *Escalator) shouldEscalate(key string, count *general.ReportOverview, now time.Time) bool {
+	policy := e.conf.policy(count.SourceID)
+	if policy.Exempt {
+		return false
+	}
+	for severity, threshold := range policy.Thresholds {
+		if e.numReports(key, count, policy, severity, now) >= threshold {
+			return true
+		}
+	}
+	if events, ok := e.events[key]; ok {
+		window := e.conf.policyRateWindow(policy)
+		for severity, rate := range policy.Rates {
+			if events.rate(now, window, severity) >= rate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// InsertReport stores the report and evaluates escalation of its source.
+// Both steps are performed as a single one with respect to Reload so
+// the report cannot be counted twice (or missed) by a concurrent reload.
+func (e *Escalator) InsertReport(report *general.Report) error {
+	pending, err := e.insertReport(report)
+	if err != nil {
+		return err
+	}
+	if err := e.deliver(pending); err != nil {
+		return fmt.Errorf("failed to handle escalation: %w", err)
+	}
+	return nil
 }
 
-func (e *Escalator) HandleEscalation(report *general.Report) error {
+func (e *Escalator) insertReport(report *general.Report) ([]notification, error) {
+	e.ingest.RLock()
+	defer e.ingest.RUnlock()
+	if err := e.store.InsertReport(report); err != nil {
+		return nil, fmt.Errorf("failed to insert report: %w", err)
+	}
+	// reports obtained during maintenance are not counted at all
+	if report.InMaintenance {
+		report.Escalated = false
+		return nil, nil
+	}
+	changes := e.handleEscalation(report)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	e.updates.Lock()
+	defer e.updates.Unlock()
+	pending, err := e.applyChanges(changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to handle escalation: %w", err)
+	}
+	return pending, nil
+}
+
+func (e *Escalator) handleEscalation(report *general.Report) []stateChange {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var changes []stateChange
 	key := e.makeKey(report.SourceID)
 	count, ok := e.counts[key]
-	if !ok {
-		count = &general.ReportOverview{SourceID: report.SourceID}
+	// the report starts a new group if the previous one has been
+	// resolved in the meantime (i.e. before the state is reloaded)
+	if !ok || count.GroupID != report.GroupID {
+		count = &general.ReportOverview{GroupID: report.GroupID, SourceID: report.SourceID, Created: report.Created}
 		e.counts[key] = count
 	}
 
@@ -59,48 +289,377 @@ func (e *Escalator) HandleEscalation(report *general.Report) error {
 	case general.SeverityLevelInfo:
 		count.Info += 1
 	}
+	if report.Severity == general.SeverityLevelCritical || report.Severity == general.SeverityLevelWarning {
+		count.LastAlert = &report.Created
+	}
+
+	e.addEvent(key, report.Severity, report.Created)
 
 	// check escalation
 	lastEscalated := count.Escalated
-	count.Escalated = count.Critical > 0 || count.Warning > escalateWarningCount
+	count.Escalated = lastEscalated || e.shouldEscalate(key, count, report.Created)
 	if !lastEscalated && count.Escalated {
-		rdb := engine.NewReportsDatabase(e.db)
-		err := rdb.EscalateGroup(report.GroupID)
-		if err != nil {
-			return fmt.Errorf("failed to handle escalation: %w", err)
+		prev, groupID := *count, count.GroupID
+		now := time.Now()
+		count.EscalationLevel = 1
+		count.EscalatedAt = &now
+		count.Reminders = 0
+		count.LastReminder = nil
+		change := stateChange{
+			update: func() error {
+				return e.store.EscalateGroup(groupID)
+			},
+			revert: func() {
+				count.Escalated = prev.Escalated
+				count.EscalationLevel = prev.EscalationLevel
+				count.EscalatedAt = prev.EscalatedAt
+				count.Reminders = prev.Reminders
+				count.LastReminder = prev.LastReminder
+			},
+		}
+		// acknowledged groups are already being handled by someone
+		// so there is no need to raise the alarm (the same applies
+		// to snoozed groups and sources)
+		if !count.Acknowledged && !e.isSnoozed(report) {
+			change.messages = append(change.messages, e.escalationMessage(count, e.tierNotifiers(1), &general.Report{
+				SourceID: report.SourceID,
+				Severity: general.SeverityLevelCritical,
+				Subject:  "Service escalated!",
+				Body:     "Subsequent notifications will be escalated",
+			}, "Escalation notification sent"))
+		}
+		changes = append(changes, change)
+	}
+	// update report escalation
+	report.Escalated = count.Escalated && !count.Acknowledged
+	return changes
+}
+
+// checkDeescalation de-escalates escalated sources which have been
+// quiet for the period specified by their policy
+func (e *Escalator) checkDeescalation(now time.Time) []stateChange {
+	var changes []stateChange
+	for _, count := range e.counts {
+		if !count.Escalated {
+			continue
+		}
+		policy := e.conf.policy(count.SourceID)
+		if policy.DeescalateAfterMins == 0 {
+			continue
+		}
+		lastAlert := count.Created
+		if count.LastAlert != nil {
+			lastAlert = *count.LastAlert
+		}
+		if now.Sub(lastAlert) < policy.quietPeriod() {
+			continue
+		}
+		// everyone involved in the escalation chain should learn about it
+		notifiers := e.tierNotifiers(count.EscalationLevel)
+		count, prev, groupID := count, *count, count.GroupID
+		count.Escalated = false
+		count.EscalationLevel = 0
+		count.Deescalated = &now
+		change := stateChange{
+			update: func() error {
+				return e.store.DeescalateGroup(groupID)
+			},
+			revert: func() {
+				count.Escalated = prev.Escalated
+				count.EscalationLevel = prev.EscalationLevel
+				count.Deescalated = prev.Deescalated
+			},
+		}
+		if !count.Acknowledged && !e.isSnoozed(&general.Report{SourceID: count.SourceID}) {
+			change.messages = append(change.messages, e.escalationMessage(count, notifiers, &general.Report{
+				SourceID: count.SourceID,
+				Severity: general.SeverityLevelRecovery,
+				Subject:  "Service de-escalated",
+				Body:     fmt.Sprintf("No critical or warning reports for %d minutes, subsequent notifications will not be escalated", policy.DeescalateAfterMins),
+			}, "De-escalation notification sent"))
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// checkPromotion promotes unacknowledged escalated groups
+// to higher tiers of the escalation chain
+func (e *Escalator) checkPromotion(now time.Time) []stateChange {
+	var changes []stateChange
+	for _, count := range e.counts {
+		if !count.Escalated || count.EscalatedAt == nil || count.Acknowledged ||
+			e.isSilenced(count.SourceID, now) {
+			continue
+		}
+		count, prevLevel, groupID := count, count.EscalationLevel, count.GroupID
+		var messages []notification
+		for count.EscalationLevel < len(e.conf.Tiers) {
+			tier := e.conf.Tiers[count.EscalationLevel]
+			if now.Sub(*count.EscalatedAt) < tier.after() {
+				break
+			}
+			level := count.EscalationLevel + 1
+			count.EscalationLevel = level
+			messages = append(messages, e.escalationMessage(count, tier.Notifiers, &general.Report{
+				SourceID: count.SourceID,
+				Severity: general.SeverityLevelCritical,
+				Subject:  fmt.Sprintf("Service escalated to level %d!", level),
+				Body:     fmt.Sprintf("The escalation has not been acknowledged for %d minutes", tier.AfterMins),
+			}, fmt.Sprintf("Level %d escalation notification sent", level)))
+		}
+		if count.EscalationLevel == prevLevel {
+			continue
 		}
-		err = e.notifiers.SendNotifications(&general.Report{
-			SourceID: report.SourceID,
-			Severity: general.SeverityLevelCritical,
-			Subject:  "Service escalated!",
-			Body:     "Subsequent notifications will be escalated",
+		level := count.EscalationLevel
+		changes = append(changes, stateChange{
+			update: func() error {
+				return e.store.PromoteGroup(groupID, level)
+			},
+			revert: func() {
+				count.EscalationLevel = prevLevel
+			},
+			messages: messages,
 		})
+	}
+	return changes
+}
+
+// applyChanges stores state changes and provides messages of the stored
+// ones. Changes which fail to be stored are reverted (all the errors are
+// returned). It must not be called with the escalator state locked.
+func (e *Escalator) applyChanges(changes []stateChange) ([]notification, error) {
+	var pending []notification
+	var errs []error
+	for _, change := range changes {
+		if change.update != nil {
+			if err := change.update(); err != nil {
+				errs = append(errs, err)
+				e.mu.Lock()
+				change.revert()
+				e.mu.Unlock()
+				continue
+			}
+		}
+		pending = append(pending, change.messages...)
+	}
+	return pending, errors.Join(errs...)
+}
+
+// tierNotifiers provides notifiers of the escalation chain up to
+// the level (nil if there is no chain configured)
+func (e *Escalator) tierNotifiers(level int) []string {
+	if len(e.conf.Tiers) == 0 {
+		return nil
+	}
+	return e.conf.tierNotifiers(level)
+}
+
+// escalationMessage prepares the message to be sent via the specified
+// notifiers (nil means all the shared notifiers) and personal notifiers
+// of the assignee. Once sent, the notification is recorded to the group
+// timeline.
+func (e *Escalator) escalationMessage(
+	count *general.ReportOverview,
+	notifiers []string,
+	message *general.Report,
+	eventBody string,
+) notification {
+	groupID := count.GroupID
+	return notification{
+		assignee:  count.AssignedToUserName,
+		notifiers: notifiers,
+		message:   message,
+		record: func() error {
+			return e.store.AddGroupEvent(&general.GroupEvent{
+				GroupID: groupID,
+				Type:    general.GroupEventNotification,
+				UserID:  -1,
+				Body:    eventBody,
+			})
+		},
+	}
+}
+
+// deliver sends pending notifications. A failed notification does not
+// prevent the other ones from being sent (all the errors are returned).
+// It must not be called with the escalator state locked.
+func (e *Escalator) deliver(pending []notification) error {
+	var errs []error
+	for _, n := range pending {
+		var err error
+		if n.notifiers == nil {
+			err = e.notifiers.SendAssigneeNotifications(n.assignee, n.message)
+		} else {
+			err = e.notifiers.SendNamedNotifications(n.notifiers, n.assignee, n.message)
+		}
 		if err != nil {
-			return fmt.Errorf("failed to handle escalation: %w", err)
+			errs = append(errs, err)
+			continue
+		}
+		if err := n.record(); err != nil {
+			errs = append(errs, err)
 		}
 	}
-	// update report escalation
-	report.Escalated = count.Escalated
+	return errors.Join(errs...)
+}
+
+// checkReminders re-sends escalation messages of groups
+// which remain unacknowledged
+func (e *Escalator) checkReminders(now time.Time) []stateChange {
+	conf := e.conf.Reminders
+	if conf == nil {
+		return nil
+	}
+	var changes []stateChange
+	for key, count := range e.counts {
+		if !count.Escalated || count.Acknowledged ||
+			e.isSilenced(count.SourceID, now) {
+			continue
+		}
+		if conf.MaxCount > 0 && count.Reminders >= conf.MaxCount {
+			continue
+		}
+		since := count.EscalatedAt
+		if count.LastReminder != nil {
+			since = count.LastReminder
+		}
+		if since == nil || now.Sub(*since) < conf.interval() {
+			continue
+		}
+		reminder, groupID, key := count.Reminders+1, count.GroupID, key
+		changes = append(changes, stateChange{messages: []notification{{
+			assignee:  count.AssignedToUserName,
+			notifiers: e.tierNotifiers(count.EscalationLevel),
+			message: &general.Report{
+				SourceID:  count.SourceID,
+				Severity:  general.SeverityLevelCritical,
+				Subject:   "Service still escalated!",
+				Body:      "The escalation has not been acknowledged yet",
+				Escalated: true,
+				Reminder:  reminder,
+			},
+			// the reminder counts only once it has been both sent
+			// and recorded (otherwise it is retried by the next check)
+			record: func() error {
+				if err := e.store.RecordReminder(groupID, reminder); err != nil {
+					return err
+				}
+				e.mu.Lock()
+				defer e.mu.Unlock()
+				if count, ok := e.counts[key]; ok && count.GroupID == groupID && count.Reminders < reminder {
+					count.Reminders = reminder
+					count.LastReminder = &now
+				}
+				return nil
+			},
+		}}})
+	}
+	return changes
+}
+
+// check performs all the periodic checks of escalated sources
+// and sends resulting notifications
+func (e *Escalator) check(now time.Time) error {
+	pending, err := e.checkAll(now)
+	// notifications of the changes stored before a failure
+	// should be sent anyway
+	if err := e.deliver(pending); err != nil {
+		return fmt.Errorf("failed to send escalation notifications: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check escalated sources: %w", err)
+	}
 	return nil
 }
 
+func (e *Escalator) checkAll(now time.Time) ([]notification, error) {
+	e.ingest.RLock()
+	defer e.ingest.RUnlock()
+	e.updates.Lock()
+	defer e.updates.Unlock()
+	e.mu.Lock()
+	changes := e.checkDeescalation(now)
+	changes = append(changes, e.checkPromotion(now)...)
+	changes = append(changes, e.checkReminders(now)...)
+	e.mu.Unlock()
+	return e.applyChanges(changes)
+}
+
+// Start runs periodic checks of escalated sources in the background
+func (e *Escalator) Start() {
+	ticker := time.NewTicker(e.conf.checkInterval())
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.done:
+				return
+			case now := <-ticker.C:
+				if err := e.check(now); err != nil {
+					log.Error().Err(err).Msg("escalator failed to check escalated sources")
+				}
+			}
+		}
+	}()
+}
+
+func (e *Escalator) Stop() {
+	close(e.done)
+}
+
+// Reload replaces the escalator state with the one obtained
+// from the store
 func (e *Escalator) Reload() error {
-	rdb := engine.NewReportsDatabase(e.db)
-	counts, err := rdb.GetOverview()
+	// the store is read without the state locked, reports being
+	// inserted and changes being stored have to wait though
+	e.ingest.Lock()
+	defer e.ingest.Unlock()
+	counts, err := e.store.GetOverview()
+	if err != nil {
+		return fmt.Errorf("failed to reload escalator: %w", err)
+	}
+	snoozes, err := e.store.ListSourceSnoozes()
 	if err != nil {
 		return fmt.Errorf("failed to reload escalator: %w", err)
 	}
-	e.counts = make(map[string]*general.ReportOverview)
+	// de-escalated sources need their reports since the de-escalation
+	from := time.Now().Add(-e.conf.maxWindow())
+	for _, count := range counts {
+		if count.Deescalated != nil && count.Deescalated.Before(from) {
+			from = *count.Deescalated
+		}
+	}
+	reports, err := e.store.ListReportTimes(from, e.conf.BufferSize)
+	if err != nil {
+		return fmt.Errorf("failed to reload escalator: %w", err)
+	}
+	events := make(map[string]sourceEvents)
+	for _, report := range reports {
+		key := e.makeKey(report.SourceID)
+		if _, ok := events[key]; !ok {
+			events[key] = make(sourceEvents)
+		}
+		events[key].add(report.Created, report.Severity, e.conf.BufferSize)
+	}
+	countsMap := make(map[string]*general.ReportOverview)
 	for _, count := range counts {
-		e.Set(count)
+		countsMap[e.makeKey(count.SourceID)] = count
 	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = events
+	e.counts = countsMap
+	e.snoozes = snoozes
 	return nil
 }
 
-func NewEscalator(sqlDB *sql.DB, notifiers *notifiers.Notifiers) (*Escalator, error) {
+func NewEscalator(conf *Conf, store engine.ReportStore, notifiers *notifiers.Notifiers) (*Escalator, error) {
 	escalator := Escalator{
-		db:        sqlDB,
+		conf:      conf,
+		store:     store,
 		notifiers: notifiers,
+		done:      make(chan struct{}),
 	}
 	if err := escalator.Reload(); err != nil {
 		return nil, err
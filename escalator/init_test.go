@@ -0,0 +1,338 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package escalator
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/czcorpus/conomi/engine"
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/notifiers"
+	"github.com/czcorpus/conomi/notifiers/common"
+)
+
+// newTestEscalator creates an escalator backed by a new SQLite database
+func newTestEscalator(t *testing.T, conf *Conf, notifierConfs ...common.NotifierConf) (*Escalator, engine.ReportStore) {
+	t.Helper()
+	dbConf := &engine.DBConf{Driver: engine.DriverSQLite, Name: filepath.Join(t.TempDir(), "conomi.db")}
+	migrator, err := engine.NewMigrator(dbConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer migrator.Close()
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	store, err := engine.Open(dbConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.ValidateAndDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	n, err := notifiers.NewNotifiers(general.GeneralInfo{}, notifierConfs, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewEscalator(conf, store, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.SetSnoozer(e)
+	return e, store
+}
+
+// postReport stores the report via the escalator
+// (the same way the report API does)
+func postReport(t *testing.T, e *Escalator, sourceID general.SourceID, severity general.SeverityLevel) *general.Report {
+	t.Helper()
+	report := &general.Report{
+		SourceID:         sourceID,
+		Severity:         severity,
+		Subject:          "test",
+		Created:          time.Now(),
+		ResolvedByUserID: -1,
+	}
+	if err := e.InsertReport(report); err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func getOverview(t *testing.T, store engine.ReportStore, sourceID general.SourceID) *general.ReportOverview {
+	t.Helper()
+	counts, err := store.GetOverview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, count := range counts {
+		if count.SourceID == sourceID {
+			return count
+		}
+	}
+	t.Fatalf("no overview of %v", sourceID)
+	return nil
+}
+
+type testMaintenance struct {
+	active bool
+}
+
+func (tm *testMaintenance) IsActive(sourceID general.SourceID, t time.Time) bool {
+	return tm.active
+}
+
+func TestChecksSkipMaintenance(t *testing.T) {
+	e, store := newTestEscalator(t, &Conf{
+		Tiers: []TierConf{
+			{AfterMins: 0, Notifiers: []string{"first"}},
+			{AfterMins: 10, Notifiers: []string{"second"}},
+		},
+		Reminders: &ReminderConf{IntervalMins: 5},
+	})
+	maintenance := &testMaintenance{active: true}
+	e.SetMaintenance(maintenance)
+	source := general.SourceID{App: "app1"}
+	if report := postReport(t, e, source, general.SeverityLevelCritical); !report.Escalated {
+		t.Fatal("expected the report to be escalated")
+	}
+
+	now := time.Now().Add(30 * time.Minute)
+	if err := e.check(now); err != nil {
+		t.Fatal(err)
+	}
+	if count := getOverview(t, store, source); count.EscalationLevel != 1 || count.Reminders != 0 {
+		t.Errorf("expected no promotion and reminders during maintenance, got level %d, %d reminders",
+			count.EscalationLevel, count.Reminders)
+	}
+
+	maintenance.active = false
+	if err := e.check(now); err != nil {
+		t.Fatal(err)
+	}
+	if count := getOverview(t, store, source); count.EscalationLevel != 2 || count.Reminders != 1 {
+		t.Errorf("expected promotion and a reminder after maintenance, got level %d, %d reminders",
+			count.EscalationLevel, count.Reminders)
+	}
+}
+
+// newTestWebhook creates a webhook notifier conf along with
+// a server counting received notifications. The server fails
+// while `failing` is set.
+func newTestWebhook(t *testing.T, name string, failing *atomic.Bool) (common.NotifierConf, *atomic.Int32) {
+	t.Helper()
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		received.Add(1)
+	}))
+	t.Cleanup(server.Close)
+	return common.NotifierConf{
+		Type: "webhook",
+		Name: name,
+		Args: map[string]any{"url": server.URL},
+	}, &received
+}
+
+func TestRemindersCountOnlyDelivered(t *testing.T) {
+	var failing atomic.Bool
+	webhook, received := newTestWebhook(t, "hook", &failing)
+	e, store := newTestEscalator(t, &Conf{Reminders: &ReminderConf{IntervalMins: 5}}, webhook)
+	source := general.SourceID{App: "app1"}
+	postReport(t, e, source, general.SeverityLevelCritical)
+	sent := received.Load()
+
+	failing.Store(true)
+	now := time.Now().Add(6 * time.Minute)
+	if err := e.check(now); err == nil {
+		t.Fatal("expected the check to fail")
+	}
+	if count := getOverview(t, store, source); count.Reminders != 0 {
+		t.Errorf("expected no stored reminder, got %d", count.Reminders)
+	}
+
+	failing.Store(false)
+	if err := e.check(now); err != nil {
+		t.Fatal(err)
+	}
+	if count := getOverview(t, store, source); count.Reminders != 1 {
+		t.Errorf("expected the reminder to be retried and stored, got %d", count.Reminders)
+	}
+	// no more reminders within the interval
+	if err := e.check(now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if got := received.Load() - sent; got != 1 {
+		t.Errorf("expected a single delivered reminder, got %d", got)
+	}
+}
+
+func TestDeliverContinuesAfterFailure(t *testing.T) {
+	var failing, working atomic.Bool
+	failing.Store(true)
+	bad, _ := newTestWebhook(t, "bad", &failing)
+	good, received := newTestWebhook(t, "good", &working)
+	e, _ := newTestEscalator(t, &Conf{}, bad, good)
+	var recorded []string
+	notify := func(name string) notification {
+		return notification{
+			notifiers: []string{name},
+			message:   &general.Report{Severity: general.SeverityLevelCritical, Subject: "test"},
+			record: func() error {
+				recorded = append(recorded, name)
+				return nil
+			},
+		}
+	}
+	failingRecord := notify("good")
+	failingRecord.record = func() error { return errors.New("record failed") }
+
+	err := e.deliver([]notification{notify("bad"), failingRecord, notify("good")})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if received.Load() != 2 {
+		t.Errorf("expected 2 notifications sent, got %d", received.Load())
+	}
+	if len(recorded) != 1 || recorded[0] != "good" {
+		t.Errorf("expected just the last notification recorded, got %v", recorded)
+	}
+}
+
+// TestConcurrentReportsAndReloads runs reports, resolving of groups,
+// reloads and periodic checks (including the ticker) concurrently and
+// verifies the resulting state matches the store. It is meant to be
+// run with the race detector.
+func TestConcurrentReportsAndReloads(t *testing.T) {
+	e, store := newTestEscalator(t, &Conf{
+		CheckIntervalSecs: 1,
+		Reminders:         &ReminderConf{IntervalMins: 1},
+	})
+	e.Start()
+	defer e.Stop()
+	sources := []general.SourceID{{App: "app1"}, {App: "app2"}, {App: "app3"}}
+
+	var posting sync.WaitGroup
+	errs := make(chan error, 100)
+	for _, source := range sources {
+		for i := 0; i < 2; i++ {
+			posting.Add(1)
+			go func(source general.SourceID, severity general.SeverityLevel) {
+				defer posting.Done()
+				for j := 0; j < 20; j++ {
+					report := &general.Report{
+						SourceID:         source,
+						Severity:         severity,
+						Subject:          "test",
+						Created:          time.Now(),
+						ResolvedByUserID: -1,
+					}
+					if err := e.InsertReport(report); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}(source, []general.SeverityLevel{general.SeverityLevelWarning, general.SeverityLevelCritical}[i])
+		}
+	}
+
+	// resolving groups, reloads and checks keep running until the reports
+	// are posted and the ticker has fired at least once
+	stop := make(chan struct{})
+	var background sync.WaitGroup
+	background.Add(2)
+	go func() {
+		defer background.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			counts, err := store.GetOverview()
+			if err != nil {
+				errs <- err
+				return
+			}
+			if len(counts) > 0 {
+				if err := store.ResolveGroup(counts[0].GroupID, 1); err != nil {
+					errs <- err
+					return
+				}
+			}
+			if err := e.Reload(); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	go func() {
+		defer background.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := e.check(time.Now()); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	posting.Wait()
+	time.Sleep(1100 * time.Millisecond)
+	close(stop)
+	background.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	counts, err := store.GetOverview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if len(counts) != len(e.counts) {
+		t.Fatalf("expected %d sources, got %d", len(counts), len(e.counts))
+	}
+	for _, expected := range counts {
+		count, ok := e.counts[e.makeKey(expected.SourceID)]
+		if !ok {
+			t.Errorf("missing source %v", expected.SourceID)
+			continue
+		}
+		if count.GroupID != expected.GroupID || count.Critical != expected.Critical ||
+			count.Warning != expected.Warning || count.Escalated != expected.Escalated {
+			t.Errorf("expected %v group %d (%d critical, %d warning, escalated %t), got group %d (%d, %d, %t)",
+				expected.SourceID, expected.GroupID, expected.Critical, expected.Warning, expected.Escalated,
+				count.GroupID, count.Critical, count.Warning, count.Escalated)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package escalator
+
+import (
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+// eventRing is a fixed size ring buffer keeping creation times
+// of the latest reports. Once full, the oldest items are overwritten.
+type eventRing struct {
+	times []time.Time
+	next  int
+	size  int
+}
+
+func (r *eventRing) add(created time.Time) {
+	r.times[r.next] = created
+	r.next = (r.next + 1) % len(r.times)
+	if r.size < len(r.times) {
+		r.size++
+	}
+}
+
+// count provides number of items created since the `since` time.
+// Reports may arrive out of order (the creation time is set by
+// the reporting application) so all the items have to be checked.
+func (r *eventRing) count(since time.Time) int {
+	var ans int
+	for i := 0; i < r.size; i++ {
+		if !r.times[i].Before(since) {
+			ans++
+		}
+	}
+	return ans
+}
+
+func newEventRing(capacity int) *eventRing {
+	return &eventRing{times: make([]time.Time, capacity)}
+}
+
+// sourceEvents keeps the latest reports of a source. Each severity has
+// its own ring so frequent reports of one severity (e.g. info) cannot
+// push out reports of other severities.
+type sourceEvents map[general.SeverityLevel]*eventRing
+
+func (se sourceEvents) add(created time.Time, severity general.SeverityLevel, capacity int) {
+	ring, ok := se[severity]
+	if !ok {
+		ring = newEventRing(capacity)
+		se[severity] = ring
+	}
+	ring.add(created)
+}
+
+// count provides number of reports created since the `since` time
+// with the `severity` (empty severity matches any)
+func (se sourceEvents) count(since time.Time, severity general.SeverityLevel) int {
+	if severity != "" {
+		if ring, ok := se[severity]; ok {
+			return ring.count(since)
+		}
+		return 0
+	}
+	var ans int
+	for _, ring := range se {
+		ans += ring.count(since)
+	}
+	return ans
+}
+
+// rate provides number of reports per minute within the `window`
+// (with the `severity`; empty severity matches any)
+func (se sourceEvents) rate(now time.Time, window time.Duration, severity general.SeverityLevel) float64 {
+	return float64(se.count(now.Add(-window), severity)) / window.Minutes()
+}
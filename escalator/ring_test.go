@@ -0,0 +1,91 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package escalator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+var testNow = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func minutesAgo(mins ...int) []time.Time {
+	ans := make([]time.Time, len(mins))
+	for i, m := range mins {
+		ans[i] = testNow.Add(-time.Duration(m) * time.Minute)
+	}
+	return ans
+}
+
+func TestEventRingCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		added    []time.Time
+		since    time.Time
+		want     int
+	}{
+		{"empty", 3, nil, testNow.Add(-time.Hour), 0},
+		{"all", 5, minutesAgo(30, 20, 10), testNow.Add(-time.Hour), 3},
+		{"window", 5, minutesAgo(30, 20, 10), testNow.Add(-15 * time.Minute), 1},
+		{"boundary", 5, minutesAgo(30, 20, 10), testNow.Add(-20 * time.Minute), 2},
+		{"overwritten", 2, minutesAgo(30, 20, 10), testNow.Add(-time.Hour), 2},
+		{"wrapped window", 3, minutesAgo(50, 40, 30, 20, 10), testNow.Add(-25 * time.Minute), 2},
+		{"out of order", 5, minutesAgo(10, 40, 20, 50), testNow.Add(-25 * time.Minute), 2},
+		{"out of order wrapped", 3, minutesAgo(10, 50, 20, 40, 5), testNow.Add(-25 * time.Minute), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := newEventRing(tt.capacity)
+			for _, created := range tt.added {
+				ring.add(created)
+			}
+			if got := ring.count(tt.since); got != tt.want {
+				t.Errorf("count() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceEvents(t *testing.T) {
+	events := make(sourceEvents)
+	events.add(testNow.Add(-10*time.Minute), general.SeverityLevelCritical, 3)
+	// chatty info reports must not push out the critical one
+	for _, created := range minutesAgo(9, 8, 7, 6, 5, 4, 3, 2, 1) {
+		events.add(created, general.SeverityLevelInfo, 3)
+	}
+	since := testNow.Add(-time.Hour)
+	tests := []struct {
+		severity general.SeverityLevel
+		want     int
+	}{
+		{general.SeverityLevelCritical, 1},
+		{general.SeverityLevelInfo, 3},
+		{general.SeverityLevelWarning, 0},
+		{"", 4},
+	}
+	for _, tt := range tests {
+		if got := events.count(since, tt.severity); got != tt.want {
+			t.Errorf("count(%q) = %d, want %d", tt.severity, got, tt.want)
+		}
+	}
+	if got := events.rate(testNow, 5*time.Minute, general.SeverityLevelInfo); got != 0.6 {
+		t.Errorf("rate() = %f, want 0.6", got)
+	}
+}
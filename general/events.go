@@ -0,0 +1,52 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package general
+
+import "time"
+
+type GroupEventType string
+
+const (
+	GroupEventCreated      GroupEventType = "created"
+	GroupEventEscalated    GroupEventType = "escalated"
+	GroupEventDeescalated  GroupEventType = "deescalated"
+	GroupEventAcknowledged GroupEventType = "acknowledged"
+	GroupEventAssigned     GroupEventType = "assigned"
+	GroupEventResolved     GroupEventType = "resolved"
+	GroupEventReopened     GroupEventType = "reopened"
+	GroupEventMerged       GroupEventType = "merged"
+	GroupEventSplit        GroupEventType = "split"
+	GroupEventSnoozed      GroupEventType = "snoozed"
+	GroupEventNotification GroupEventType = "notification"
+	GroupEventComment      GroupEventType = "comment"
+)
+
+func (et GroupEventType) String() string {
+	return string(et)
+}
+
+// GroupEvent is a single item of a report group timeline.
+// Body contains Markdown text (for comments it is the comment itself).
+type GroupEvent struct {
+	ID       int            `json:"id"`
+	GroupID  int            `json:"groupId"`
+	Type     GroupEventType `json:"type"`
+	UserID   int            `json:"userId"` // for empty user we use value -1
+	UserName string         `json:"userName"`
+	Body     string         `json:"body"`
+	Created  time.Time      `json:"created"`
+}
@@ -0,0 +1,43 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package general
+
+import "time"
+
+// MaintenanceWindow specifies a period during which reports
+// of an app (or an app instance) are not escalated nor notified.
+// A one-off window is given by Start and End, a recurring one
+// by a cron expression and a duration (Start and End can be
+// used to limit the validity of the recurring window).
+type MaintenanceWindow struct {
+	ID           int        `json:"id"`
+	App          string     `json:"app"`
+	Instance     string     `json:"instance"` // empty matches any instance
+	Start        *time.Time `json:"start,omitempty"`
+	End          *time.Time `json:"end,omitempty"`
+	Cron         string     `json:"cron,omitempty"`
+	DurationMins int        `json:"durationMins,omitempty"`
+	Description  string     `json:"description"`
+	UserID       int        `json:"userId"` // for empty user we use value -1
+	UserName     string     `json:"userName"`
+	Created      time.Time  `json:"created"`
+}
+
+// Matches tells whether the window applies to the source
+func (mw *MaintenanceWindow) Matches(sourceID SourceID) bool {
+	return mw.App == sourceID.App && (mw.Instance == "" || mw.Instance == sourceID.Instance)
+}
@@ -38,15 +38,77 @@ type Report struct {
 	ResolvedByUserID   int            `json:"resolvedByUserId"` // for empty user we use value -1
 	ResolvedByUserName string         `json:"resolvedByUserName"`
 	Escalated          bool           `json:"escalated"`
+	InMaintenance      bool           `json:"inMaintenance"`
+
+	// Reminder is the number of a reminder of an unacknowledged
+	// escalation (zero for regular notifications). It is never stored.
+	Reminder int `json:"-"`
 }
 
 type ReportOverview struct {
-	SourceID  SourceID  `json:"sourceId"`
-	Escalated bool      `json:"escalated"`
-	Critical  int       `json:"critical"`
-	Warning   int       `json:"warning"`
-	Info      int       `json:"info"`
-	Recent    int       `json:"recent"`
-	Created   time.Time `json:"created"`
-	Last      time.Time `json:"last"`
+	GroupID                int        `json:"groupId"`
+	SourceID               SourceID   `json:"sourceId"`
+	Escalated              bool       `json:"escalated"`
+	EscalationLevel        int        `json:"escalationLevel"`
+	EscalatedAt            *time.Time `json:"escalatedAt,omitempty"`
+	Deescalated            *time.Time `json:"deescalated,omitempty"`
+	Reminders              int        `json:"reminders"`
+	LastReminder           *time.Time `json:"lastReminder,omitempty"`
+	Acknowledged           bool       `json:"acknowledged"`
+	AcknowledgedByUserName string     `json:"acknowledgedByUserName"`
+	AssignedToUserName     string     `json:"assignedToUserName"`
+	SnoozedUntil           *time.Time `json:"snoozedUntil,omitempty"`
+	Critical               int        `json:"critical"`
+	Warning                int        `json:"warning"`
+	Info                   int        `json:"info"`
+	Recent                 int        `json:"recent"`
+	Rate                   float64    `json:"rate"`                // reports per minute
+	LastAlert              *time.Time `json:"lastAlert,omitempty"` // last critical or warning report
+	Created                time.Time  `json:"created"`
+	Last                   time.Time  `json:"last"`
+}
+
+// ReportGroup is a group of reports from the same source
+// representing a single incident. An unresolved group can be
+// acknowledged to express that someone is working on it and
+// assigned to a user who owns the incident.
+type ReportGroup struct {
+	ID                     int                   `json:"id"`
+	SourceID               SourceID              `json:"sourceId"`
+	Created                time.Time             `json:"created"`
+	Escalated              bool                  `json:"escalated"`
+	ResolvedByUserID       int                   `json:"resolvedByUserId"` // for empty user we use value -1
+	ResolvedByUserName     string                `json:"resolvedByUserName"`
+	AcknowledgedByUserID   int                   `json:"acknowledgedByUserId"` // for empty user we use value -1
+	AcknowledgedByUserName string                `json:"acknowledgedByUserName"`
+	Acknowledged           *time.Time            `json:"acknowledged,omitempty"`
+	AssignedToUserID       int                   `json:"assignedToUserId"` // for empty user we use value -1
+	AssignedToUserName     string                `json:"assignedToUserName"`
+	SnoozedUntil           *time.Time            `json:"snoozedUntil,omitempty"`
+	ReportCount            int                   `json:"reportCount"`
+	FirstSeen              time.Time             `json:"firstSeen"`
+	LastSeen               time.Time             `json:"lastSeen"`
+	Severities             map[SeverityLevel]int `json:"severities"`
+}
+
+type ReportGroupsPage struct {
+	Groups []*ReportGroup `json:"groups"`
+	Total  int            `json:"total"`
+}
+
+// SourceSnooze silences notifications of a source until a given time.
+// Empty Instance or Tag matches any instance or tag of the App.
+type SourceSnooze struct {
+	SourceID     SourceID  `json:"sourceId"`
+	SnoozedUntil time.Time `json:"snoozedUntil"`
+	UserID       int       `json:"userId"` // for empty user we use value -1
+	UserName     string    `json:"userName"`
+	Created      time.Time `json:"created"`
+}
+
+// Matches tells whether the snooze applies to the source
+func (ss *SourceSnooze) Matches(sourceID SourceID) bool {
+	return ss.SourceID.App == sourceID.App &&
+		(ss.SourceID.Instance == "" || ss.SourceID.Instance == sourceID.Instance) &&
+		(ss.SourceID.Tag == "" || ss.SourceID.Tag == sourceID.Tag)
 }
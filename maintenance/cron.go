@@ -0,0 +1,215 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maintenance
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7}, // both 0 and 7 stand for Sunday
+}
+
+// CronExpr is a parsed standard five-field cron expression
+// (minute, hour, day of month, month, day of week). Each field
+// supports `*`, single values, ranges (`1-5`), steps (`*/15`, `1-30/2`)
+// and comma separated lists of these.
+type CronExpr struct {
+	fields [5]uint64
+
+	// in accordance with the standard cron, in case both day fields
+	// are restricted, a time matches if any of them matches
+	anyDay     bool
+	anyWeekday bool
+}
+
+func parseCronValue(value string, field cronField) (int, error) {
+	ans, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value `%s`", field.name, value)
+	}
+	if ans < field.min || ans > field.max {
+		return 0, fmt.Errorf("%s value %d out of range %d-%d", field.name, ans, field.min, field.max)
+	}
+	return ans, nil
+}
+
+func parseCronField(value string, field cronField) (uint64, error) {
+	var ans uint64
+	for _, item := range strings.Split(value, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid %s step `%s`", field.name, stepStr)
+			}
+		}
+		from, to := field.min, field.max
+		if rng != "*" {
+			fromStr, toStr, isRange := strings.Cut(rng, "-")
+			var err error
+			from, err = parseCronValue(fromStr, field)
+			if err != nil {
+				return 0, err
+			}
+			to = from
+			if isRange {
+				to, err = parseCronValue(toStr, field)
+				if err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				to = field.max
+			}
+			if to < from {
+				return 0, fmt.Errorf("invalid %s range `%s`", field.name, rng)
+			}
+		}
+		for i := from; i <= to; i += step {
+			ans |= 1 << uint(i)
+		}
+	}
+	return ans, nil
+}
+
+// ParseCron parses a five-field cron expression
+func ParseCron(expr string) (*CronExpr, error) {
+	items := strings.Fields(expr)
+	if len(items) != len(cronFields) {
+		return nil, fmt.Errorf(
+			"invalid cron expression `%s`: expected %d fields, got %d", expr, len(cronFields), len(items))
+	}
+	ans := &CronExpr{
+		anyDay:     items[2] == "*",
+		anyWeekday: items[4] == "*",
+	}
+	for i, item := range items {
+		value, err := parseCronField(item, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression `%s`: %w", expr, err)
+		}
+		ans.fields[i] = value
+	}
+	// Sunday can be specified both as 0 and 7
+	if ans.fields[4]&(1<<7) > 0 {
+		ans.fields[4] |= 1
+	}
+	return ans, nil
+}
+
+func (c *CronExpr) has(field int, value int) bool {
+	return c.fields[field]&(1<<uint(value)) > 0
+}
+
+// matchesDay tells whether the expression matches the date
+// (without considering the time of the day)
+func (c *CronExpr) matchesDay(t time.Time) bool {
+	if !c.has(3, int(t.Month())) {
+		return false
+	}
+	dayMatches := c.has(2, t.Day())
+	weekdayMatches := c.has(4, int(t.Weekday()))
+	if c.anyDay || c.anyWeekday {
+		return dayMatches && weekdayMatches
+	}
+	return dayMatches || weekdayMatches
+}
+
+// Matches tells whether the expression matches the minute
+// of the time `t` (seconds are ignored)
+func (c *CronExpr) Matches(t time.Time) bool {
+	return c.has(0, t.Minute()) && c.has(1, t.Hour()) && c.matchesDay(t)
+}
+
+// upTo provides values of a field not greater than `limit`
+func (c *CronExpr) upTo(field int, limit int) uint64 {
+	return c.fields[field] & (1<<uint(limit+1) - 1)
+}
+
+// latestOccurrence provides the latest occurrence of the wall clock time
+// of `clock` which is not after `t`. Most times occur just once but
+// a time repeated due to a DST change has two occurrences an hour apart.
+func latestOccurrence(clock time.Time, t time.Time) (time.Time, bool) {
+	for _, ans := range []time.Time{clock.Add(time.Hour), clock, clock.Add(-time.Hour)} {
+		if ans.After(t) {
+			continue
+		}
+		if ans.Equal(clock) || ans.Hour() == clock.Hour() && ans.Minute() == clock.Minute() {
+			return ans, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Prev provides the latest time (with minute precision) matching
+// the expression which is neither after `t` nor before `earliest`.
+// Times are evaluated in the location of `t`. A time skipped due to
+// a DST change is moved forward by the length of the gap (e.g. 2:30
+// becomes 3:30) and a repeated time matches both of its occurrences.
+func (c *CronExpr) Prev(t time.Time, earliest time.Time) (time.Time, bool) {
+	year, month, day := t.Date()
+	for i := 0; ; i++ {
+		date := time.Date(year, month, day-i, 0, 0, 0, 0, t.Location())
+		if date.AddDate(0, 0, 1).Before(earliest) {
+			return time.Time{}, false
+		}
+		if !c.matchesDay(date) {
+			continue
+		}
+		maxHour := 23
+		if i == 0 {
+			maxHour = t.Hour()
+		}
+		for hours := c.upTo(1, maxHour); hours != 0; {
+			hour := bits.Len64(hours) - 1
+			hours &^= 1 << uint(hour)
+			maxMinute := 59
+			if i == 0 && hour == t.Hour() {
+				maxMinute = t.Minute()
+			}
+			for minutes := c.upTo(0, maxMinute); minutes != 0; {
+				minute := bits.Len64(minutes) - 1
+				minutes &^= 1 << uint(minute)
+				ans, ok := latestOccurrence(
+					time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, t.Location()), t)
+				if !ok {
+					continue
+				}
+				if ans.Before(earliest) {
+					return time.Time{}, false
+				}
+				return ans, true
+			}
+		}
+	}
+}
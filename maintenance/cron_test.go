@@ -0,0 +1,168 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		field   int
+		want    []int
+		wantErr bool
+	}{
+		{"any minute", "* * * * *", 0, seq(0, 59, 1), false},
+		{"single value", "5 * * * *", 0, []int{5}, false},
+		{"list", "0 8,12,18 * * *", 1, []int{8, 12, 18}, false},
+		{"range", "0 9-17 * * *", 1, seq(9, 17, 1), false},
+		{"step", "*/15 * * * *", 0, []int{0, 15, 30, 45}, false},
+		{"range with step", "0 * 1-10/3 * *", 2, []int{1, 4, 7, 10}, false},
+		{"value with step", "0 * * 2/5 *", 3, []int{2, 7, 12}, false},
+		{"sunday as 7", "0 0 * * 7", 4, []int{0, 7}, false},
+		{"mixed list", "0 0 * * 1-3,5", 4, []int{1, 2, 3, 5}, false},
+		{"too few fields", "* * * *", 0, nil, true},
+		{"too many fields", "* * * * * *", 0, nil, true},
+		{"out of range", "60 * * * *", 0, nil, true},
+		{"zero day", "0 0 0 * *", 0, nil, true},
+		{"reversed range", "0 17-9 * * *", 0, nil, true},
+		{"zero step", "*/0 * * * *", 0, nil, true},
+		{"invalid step", "*/x * * * *", 0, nil, true},
+		{"invalid value", "a * * * *", 0, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseCron(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCron(%q) error = %v, wantErr %t", tt.expr, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var want uint64
+			for _, v := range tt.want {
+				want |= 1 << uint(v)
+			}
+			if expr.fields[tt.field] != want {
+				t.Errorf("ParseCron(%q) field %d = %b, want %b", tt.expr, tt.field, expr.fields[tt.field], want)
+			}
+		})
+	}
+}
+
+func seq(from, to, step int) []int {
+	var ans []int
+	for i := from; i <= to; i += step {
+		ans = append(ans, i)
+	}
+	return ans
+}
+
+func TestCronMatches(t *testing.T) {
+	// 2024-03-01 is Friday
+	tests := []struct {
+		name string
+		expr string
+		t    time.Time
+		want bool
+	}{
+		{"exact", "30 2 * * *", time.Date(2024, 3, 1, 2, 30, 45, 0, time.UTC), true},
+		{"other minute", "30 2 * * *", time.Date(2024, 3, 1, 2, 31, 0, 0, time.UTC), false},
+		{"month", "0 0 * 4 *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), false},
+		{"weekday only", "0 0 * * 5", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"other weekday", "0 0 * * 1", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), false},
+		{"day only", "0 0 1 * *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		// both day fields restricted - any of them is enough
+		{"day or weekday by day", "0 0 1 * 1", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"day or weekday by weekday", "0 0 15 * 5", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"day or weekday none", "0 0 15 * 1", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), false},
+		// one of the day fields unrestricted - both must match
+		{"day and any weekday", "0 0 15 * *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), false},
+		{"sunday as 7", "0 0 * * 7", time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), true},
+		{"sunday as 0", "0 0 * * 0", time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := expr.Matches(tt.t); got != tt.want {
+				t.Errorf("ParseCron(%q).Matches(%v) = %t, want %t", tt.expr, tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronPrev(t *testing.T) {
+	prague, err := time.LoadLocation("Europe/Prague")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, prague)
+	}
+	tests := []struct {
+		name     string
+		expr     string
+		t        time.Time
+		earliest time.Time
+		want     time.Time
+		found    bool
+	}{
+		{"same minute", "30 2 * * *", at(3, 1, 2, 30).Add(20 * time.Second), at(3, 1, 0, 0), at(3, 1, 2, 30), true},
+		{"earlier today", "30 2 * * *", at(3, 1, 8, 0), at(3, 1, 0, 0), at(3, 1, 2, 30), true},
+		{"yesterday", "30 2 * * *", at(3, 1, 2, 0), at(2, 29, 0, 0), at(2, 29, 2, 30), true},
+		{"too early", "30 2 * * *", at(3, 1, 2, 0), at(2, 29, 3, 0), time.Time{}, false},
+		{"latest of many", "*/15 9-17 * * *", at(3, 1, 12, 44), at(3, 1, 0, 0), at(3, 1, 12, 30), true},
+		{"previous hour", "*/15 9-17 * * *", at(3, 1, 18, 30), at(3, 1, 0, 0), at(3, 1, 17, 45), true},
+		{"last week", "0 22 * * 0", at(3, 1, 12, 0), at(2, 23, 0, 0), at(2, 25, 22, 0), true},
+		{"previous month", "0 0 31 * *", at(3, 1, 12, 0), at(1, 25, 0, 0), at(1, 31, 0, 0), true},
+		// 2:30 does not exist on 2024-03-31 in Prague (clocks jump from 2:00 to 3:00)
+		{
+			"skipped time", "30 2 * * *",
+			at(3, 31, 4, 0), at(3, 31, 0, 0),
+			time.Date(2024, 3, 31, 1, 30, 0, 0, time.UTC), true,
+		},
+		// 2:30 occurs twice on 2024-10-27 in Prague (clocks jump from 3:00 back to 2:00)
+		{
+			"repeated time first", "30 2 * * *",
+			time.Date(2024, 10, 27, 0, 45, 0, 0, time.UTC), at(10, 27, 0, 0),
+			time.Date(2024, 10, 27, 0, 30, 0, 0, time.UTC), true,
+		},
+		{
+			"repeated time second", "30 2 * * *",
+			time.Date(2024, 10, 27, 1, 45, 0, 0, time.UTC).In(prague), at(10, 27, 0, 0),
+			time.Date(2024, 10, 27, 1, 30, 0, 0, time.UTC), true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, found := expr.Prev(tt.t.In(prague), tt.earliest)
+			if found != tt.found || !got.Equal(tt.want) {
+				t.Errorf("ParseCron(%q).Prev(%v) = %v, %t, want %v, %t", tt.expr, tt.t, got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
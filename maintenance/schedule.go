@@ -0,0 +1,131 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maintenance
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/czcorpus/conomi/engine"
+	"github.com/czcorpus/conomi/general"
+)
+
+// maxDurationMins limits duration of recurring windows
+// (it also limits the search for the window start)
+const maxDurationMins = 7 * 24 * 60
+
+type window struct {
+	general.MaintenanceWindow
+	cron *CronExpr
+}
+
+func (w *window) isActive(t time.Time) bool {
+	if w.Start != nil && t.Before(*w.Start) {
+		return false
+	}
+	if w.End != nil && !t.Before(*w.End) {
+		return false
+	}
+	if w.cron == nil {
+		return true
+	}
+	// search for a window start within the last `DurationMins` minutes
+	earliest := t.Truncate(time.Minute).Add(-time.Duration(w.DurationMins-1) * time.Minute)
+	_, ok := w.cron.Prev(t, earliest)
+	return ok
+}
+
+// Validate checks whether the window is properly specified
+func Validate(mw *general.MaintenanceWindow) error {
+	if mw.App == "" {
+		return fmt.Errorf("missing app")
+	}
+	if mw.Start != nil && mw.End != nil && !mw.End.After(*mw.Start) {
+		return fmt.Errorf("window end must be after its start")
+	}
+	if mw.Cron == "" {
+		if mw.Start == nil || mw.End == nil {
+			return fmt.Errorf("one-off window requires both start and end")
+		}
+		return nil
+	}
+	if _, err := ParseCron(mw.Cron); err != nil {
+		return err
+	}
+	if mw.DurationMins <= 0 || mw.DurationMins > maxDurationMins {
+		return fmt.Errorf("durationMins must be between 1 and %d", maxDurationMins)
+	}
+	return nil
+}
+
+// Schedule keeps all the maintenance windows in memory and tells
+// whether a source is under maintenance. Recurring windows are
+// evaluated in the configured time zone.
+type Schedule struct {
+	mu      sync.RWMutex
+	windows []*window
+	store   engine.ReportStore
+	loc     *time.Location
+}
+
+func (s *Schedule) Reload() error {
+	items, err := s.store.ListMaintenanceWindows()
+	if err != nil {
+		return fmt.Errorf("failed to reload maintenance schedule: %w", err)
+	}
+	windows := make([]*window, 0, len(items))
+	for _, item := range items {
+		w := &window{MaintenanceWindow: *item}
+		if item.Cron != "" {
+			w.cron, err = ParseCron(item.Cron)
+			if err != nil {
+				return fmt.Errorf("failed to reload maintenance schedule: %w", err)
+			}
+		}
+		windows = append(windows, w)
+	}
+	s.mu.Lock()
+	s.windows = windows
+	s.mu.Unlock()
+	return nil
+}
+
+// IsActive tells whether there is a maintenance window
+// of the source active at the time `t`
+func (s *Schedule) IsActive(sourceID general.SourceID, t time.Time) bool {
+	t = t.In(s.loc)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, w := range s.windows {
+		if w.Matches(sourceID) && w.isActive(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func NewSchedule(store engine.ReportStore, loc *time.Location) (*Schedule, error) {
+	schedule := &Schedule{
+		store: store,
+		loc:   loc,
+	}
+	if err := schedule.Reload(); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+func TestWindowIsActive(t *testing.T) {
+	prague, err := time.LoadLocation("Europe/Prague")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, prague)
+	end := time.Date(2024, 3, 15, 0, 0, 0, 0, prague)
+	tests := []struct {
+		name     string
+		window   general.MaintenanceWindow
+		t        time.Time
+		expected bool
+	}{
+		{"one-off inside", general.MaintenanceWindow{Start: &start, End: &end}, start.Add(time.Hour), true},
+		{"one-off end", general.MaintenanceWindow{Start: &start, End: &end}, end, false},
+		{"one-off before", general.MaintenanceWindow{Start: &start, End: &end}, start.Add(-time.Second), false},
+		{"recurring start", general.MaintenanceWindow{Cron: "0 2 * * *", DurationMins: 60}, start.Add(2 * time.Hour), true},
+		{"recurring last minute", general.MaintenanceWindow{Cron: "0 2 * * *", DurationMins: 60}, start.Add(179 * time.Minute), true},
+		{"recurring after", general.MaintenanceWindow{Cron: "0 2 * * *", DurationMins: 60}, start.Add(3 * time.Hour), false},
+		{"recurring over midnight", general.MaintenanceWindow{Cron: "0 23 * * *", DurationMins: 120}, start.Add(30 * time.Minute), true},
+		{"weekly", general.MaintenanceWindow{Cron: "0 22 * * 0", DurationMins: 7 * 24 * 60}, start, true},
+		{
+			"recurring within range", general.MaintenanceWindow{Start: &start, End: &end, Cron: "0 2 * * *", DurationMins: 60},
+			end.Add(2 * time.Hour), false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &window{MaintenanceWindow: tt.window}
+			if tt.window.Cron != "" {
+				if w.cron, err = ParseCron(tt.window.Cron); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := w.isActive(tt.t.In(prague)); got != tt.expected {
+				t.Errorf("isActive(%v) = %t, want %t", tt.t, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestWindowIsActiveExhaustive compares the window evaluation with
+// checking each minute of the window duration
+func TestWindowIsActiveExhaustive(t *testing.T) {
+	prague, err := time.LoadLocation("Europe/Prague")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	isActive := func(cron *CronExpr, durationMins int, t time.Time) bool {
+		start := t.Truncate(time.Minute)
+		for i := 0; i < durationMins; i++ {
+			if cron.Matches(start.Add(-time.Duration(i) * time.Minute)) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, expr := range []string{"*/20 1-3 * * *", "45 23 * * 1-5", "0 12 1,15 * 6"} {
+		cron, err := ParseCron(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := &window{MaintenanceWindow: general.MaintenanceWindow{Cron: expr, DurationMins: 90}, cron: cron}
+		// includes the 2024-03-31 DST change (skipped times are
+		// not compared as the brute force never sees them)
+		for tm := time.Date(2024, 3, 25, 0, 0, 0, 0, prague); tm.Before(time.Date(2024, 4, 2, 0, 0, 0, 0, prague)); tm = tm.Add(7 * time.Minute) {
+			if tm.Month() == time.March && tm.Day() == 31 && tm.Hour() < 5 {
+				continue
+			}
+			if got, want := w.isActive(tm), isActive(cron, 90, tm); got != want {
+				t.Errorf("%s: isActive(%v) = %t, want %t", expr, tm, got, want)
+			}
+		}
+	}
+}
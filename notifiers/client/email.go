@@ -54,6 +54,7 @@ func (en *emailNotifier) SendNotification(report *general.Report) error {
 			NotifierName: en.name,
 			Report:       *report,
 			Info:         en.info,
+			Reminder:     report.Reminder > 0,
 		},
 	); err != nil {
 		return fmt.Errorf("failed to evaluate notification template: %w", err)
@@ -62,6 +63,9 @@ func (en *emailNotifier) SendNotification(report *general.Report) error {
 	if report.Escalated {
 		subject = "[ESCALATED] " + subject
 	}
+	if report.Reminder > 0 {
+		subject = "[REMINDER] " + subject
+	}
 	if len(report.SourceID.Instance) > 0 {
 		subject += " (" + report.SourceID.App + "/" + report.SourceID.Instance + ")"
 	} else {
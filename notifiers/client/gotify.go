@@ -0,0 +1,127 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/notifiers/common"
+	"github.com/rs/zerolog/log"
+)
+
+// dfltGotifyPriorities uses Gotify priorities 0 - 10
+// (clients usually alert loudly from 8)
+var dfltGotifyPriorities = map[general.SeverityLevel]int{
+	general.SeverityLevelRecovery: 2,
+	general.SeverityLevelInfo:     4,
+	general.SeverityLevelWarning:  6,
+	general.SeverityLevelCritical: 9,
+}
+
+type GotifyNotifierArgs struct {
+	Server string `json:"server"`
+
+	// AppToken is a token of the Gotify application
+	// the messages are sent as
+	AppToken string `json:"appToken"`
+
+	// Priorities override default mapping of severity levels
+	// to Gotify priorities (0 - 10)
+	Priorities map[general.SeverityLevel]int `json:"priorities"`
+}
+
+type gotifyMessage struct {
+	Title    string         `json:"title"`
+	Message  string         `json:"message"`
+	Priority int            `json:"priority"`
+	Extras   map[string]any `json:"extras"`
+}
+
+type gotifyNotifier struct {
+	name       string
+	info       general.GeneralInfo
+	args       *GotifyNotifierArgs
+	filter     common.FilterConf
+	priorities map[general.SeverityLevel]int
+	client     *http.Client
+}
+
+func (gn *gotifyNotifier) ShouldBeSent(report *general.Report) bool {
+	return gn.filter.IsFiltered(report)
+}
+
+func (gn *gotifyNotifier) SendNotification(report *general.Report) error {
+	extras := map[string]any{
+		"client::display": map[string]any{"contentType": "text/markdown"},
+	}
+	if clickURL := pushClickURL(gn.info, report); clickURL != "" {
+		extras["client::notification"] = map[string]any{
+			"click": map[string]any{"url": clickURL},
+		}
+	}
+	messageURL, err := url.JoinPath(gn.args.Server, "message")
+	if err != nil {
+		return fmt.Errorf("failed to send Gotify notification: %w", err)
+	}
+	err = postPushMessage(
+		gn.client,
+		gn.info,
+		messageURL,
+		map[string]string{"X-Gotify-Key": gn.args.AppToken},
+		&gotifyMessage{
+			Title:    pushTitle(report),
+			Message:  pushMessage(report),
+			Priority: gn.priorities[report.Severity],
+			Extras:   extras,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to send Gotify notification: %w", err)
+	}
+	return nil
+}
+
+func NewGotifyNotifier(
+	conf *common.NotifierConf,
+	info general.GeneralInfo,
+	args *GotifyNotifierArgs,
+) (common.Notifier, error) {
+	if _, err := url.ParseRequestURI(args.Server); err != nil {
+		return nil, fmt.Errorf("invalid gotify server: %w", err)
+	}
+	if args.AppToken == "" {
+		return nil, errors.New("gotify appToken not set")
+	}
+	priorities, err := pushPriorities(dfltGotifyPriorities, args.Priorities, 0, 10)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gotify priorities: %w", err)
+	}
+	log.Info().Msgf("creating gotify notifier `%s` for server %s", conf.Name, args.Server)
+	notifier := &gotifyNotifier{
+		name:       conf.Name,
+		info:       info,
+		args:       args,
+		filter:     conf.Filter,
+		priorities: priorities,
+		client:     &http.Client{Timeout: pushTimeout},
+	}
+	return notifier, nil
+}
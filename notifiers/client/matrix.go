@@ -0,0 +1,210 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/notifiers/common"
+	"github.com/czcorpus/conomi/reporting/content"
+	"github.com/czcorpus/conomi/templates"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	matrixTimeout = 10 * time.Second
+
+	// matrixMaxAttempts specifies how many times a message is sent
+	// in case of a network or server error. Thanks to the transaction ID
+	// reused by all the attempts, the homeserver never accepts the same
+	// message twice.
+	matrixMaxAttempts = 3
+
+	matrixRetryDelay = 5 * time.Second
+)
+
+type MatrixNotifierArgs struct {
+	Homeserver  string `json:"homeserver"`
+	AccessToken string `json:"accessToken"`
+	RoomID      string `json:"roomId"`
+}
+
+type matrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format"`
+	FormattedBody string `json:"formatted_body"`
+}
+
+type matrixNotifier struct {
+	name   string
+	info   general.GeneralInfo
+	args   *MatrixNotifierArgs
+	filter common.FilterConf
+	tmpl   *template.Template
+	client *http.Client
+
+	// txnCounter makes transaction IDs unique within the process
+	txnCounter atomic.Int64
+	txnPrefix  string
+
+	// retryDelay is multiplied by the number of failed attempts
+	retryDelay time.Duration
+}
+
+func (mn *matrixNotifier) ShouldBeSent(report *general.Report) bool {
+	return mn.filter.IsFiltered(report)
+}
+
+func (mn *matrixNotifier) newTxnID() string {
+	return fmt.Sprintf("%s-%d", mn.txnPrefix, mn.txnCounter.Add(1))
+}
+
+// put sends the event and tells whether the request
+// can be repeated in case of an error
+func (mn *matrixNotifier) put(eventURL string, payload []byte) (bool, error) {
+	req, err := http.NewRequest("PUT", eventURL, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+mn.args.AccessToken)
+	req.Header.Set("User-Agent", fmt.Sprintf("CNKNotifier/%s-%s", mn.info.Build.Version, mn.info.Build.GitCommit))
+
+	resp, err := mn.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return true, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("server responded with %d: %s", resp.StatusCode, body)
+	}
+
+	log.Debug().Bytes("response", body).Msg("performed matrix put")
+	return false, nil
+}
+
+// retry repeats a failed put in the background so the caller
+// (possibly handling an incoming report) is not blocked
+func (mn *matrixNotifier) retry(eventURL string, payload []byte) {
+	for attempt := 2; attempt <= matrixMaxAttempts; attempt++ {
+		time.Sleep(time.Duration(attempt-1) * mn.retryDelay)
+		retry, err := mn.put(eventURL, payload)
+		if err == nil {
+			return
+		}
+		if !retry || attempt == matrixMaxAttempts {
+			log.Error().Err(err).Int("attempt", attempt).Msg("failed to send Matrix notification")
+			return
+		}
+		log.Warn().Err(err).Int("attempt", attempt).Msg("failed to send Matrix notification, going to retry")
+	}
+}
+
+// SendNotification sends the report to the room. Network and server
+// errors are not returned, the message is re-sent in the background
+// instead (see matrixMaxAttempts).
+func (mn *matrixNotifier) SendNotification(report *general.Report) error {
+	var message strings.Builder
+	if err := mn.tmpl.Execute(
+		&message,
+		templates.NotificationTemplateData{
+			NotifierName: mn.name,
+			Report:       *report,
+			Info:         mn.info,
+			Reminder:     report.Reminder > 0,
+		},
+	); err != nil {
+		return fmt.Errorf("failed to send Matrix notification: %w", err)
+	}
+	payload, err := json.Marshal(matrixMessage{
+		MsgType:       "m.text",
+		Body:          message.String(),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: content.MarkdownToHTML(message.String()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send Matrix notification: %w", err)
+	}
+	// the transaction ID is shared by all the attempts to send the message
+	eventURL, err := url.JoinPath(
+		mn.args.Homeserver, "_matrix", "client", "v3", "rooms", mn.args.RoomID,
+		"send", "m.room.message", mn.newTxnID(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to send Matrix notification: %w", err)
+	}
+	retry, err := mn.put(eventURL, payload)
+	if err != nil && !retry {
+		return fmt.Errorf("failed to send Matrix notification: %w", err)
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to send Matrix notification, going to retry in the background")
+		go mn.retry(eventURL, payload)
+	}
+	return nil
+}
+
+func NewMatrixNotifier(
+	conf *common.NotifierConf,
+	info general.GeneralInfo,
+	args *MatrixNotifierArgs,
+) (common.Notifier, error) {
+	if args.Homeserver == "" {
+		return nil, errors.New("matrix homeserver not set")
+	}
+	if args.AccessToken == "" {
+		return nil, errors.New("matrix accessToken not set")
+	}
+	if !strings.HasPrefix(args.RoomID, "!") {
+		return nil, fmt.Errorf("invalid matrix roomId `%s`, room IDs start with `!`", args.RoomID)
+	}
+	tmpl, err := templates.GetTemplate(filepath.Join(conf.TplDirPath, "matrix.gtpl"))
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Msgf("creating matrix notifier `%s` for room %s", conf.Name, args.RoomID)
+	notifier := &matrixNotifier{
+		name:       conf.Name,
+		info:       info,
+		args:       args,
+		filter:     conf.Filter,
+		tmpl:       tmpl,
+		client:     &http.Client{Timeout: matrixTimeout},
+		txnPrefix:  fmt.Sprintf("conomi-%d", time.Now().UnixNano()),
+		retryDelay: matrixRetryDelay,
+	}
+	return notifier, nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/notifiers/common"
+)
+
+type matrixRequest struct {
+	path          string
+	authorization string
+	message       matrixMessage
+}
+
+// newTestMatrixServer creates a homeserver responding with the statuses
+// (and 200 once they run out) and passing received requests to the channel
+func newTestMatrixServer(t *testing.T, statuses ...int) (*httptest.Server, chan matrixRequest) {
+	t.Helper()
+	requests := make(chan matrixRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := matrixRequest{path: r.URL.Path, authorization: r.Header.Get("Authorization")}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &request.message); err != nil {
+			t.Error(err)
+		}
+		if len(statuses) > 0 {
+			w.WriteHeader(statuses[0])
+			statuses = statuses[1:]
+		}
+		requests <- request
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func newTestMatrixNotifier(t *testing.T, homeserver string) *matrixNotifier {
+	t.Helper()
+	notifier, err := NewMatrixNotifier(
+		&common.NotifierConf{Name: "matrix", TplDirPath: "../../templates"},
+		general.GeneralInfo{},
+		&MatrixNotifierArgs{Homeserver: homeserver, AccessToken: "secret", RoomID: "!room:example.com"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mn := notifier.(*matrixNotifier)
+	mn.retryDelay = time.Millisecond
+	return mn
+}
+
+func receiveMatrixRequest(t *testing.T, requests chan matrixRequest) matrixRequest {
+	t.Helper()
+	select {
+	case request := <-requests:
+		return request
+	case <-time.After(5 * time.Second):
+		t.Fatal("no request received")
+		return matrixRequest{}
+	}
+}
+
+func TestMatrixSendNotification(t *testing.T) {
+	server, requests := newTestMatrixServer(t)
+	mn := newTestMatrixNotifier(t, server.URL)
+	report := &general.Report{SourceID: general.SourceID{App: "app"}, Severity: general.SeverityLevelCritical, Subject: "subject"}
+	if err := mn.SendNotification(report); err != nil {
+		t.Fatal(err)
+	}
+	first := receiveMatrixRequest(t, requests)
+	prefix := "/_matrix/client/v3/rooms/!room:example.com/send/m.room.message/"
+	if !strings.HasPrefix(first.path, prefix) {
+		t.Errorf("expected path starting with %s, got %s", prefix, first.path)
+	}
+	if first.authorization != "Bearer secret" {
+		t.Errorf("unexpected authorization %q", first.authorization)
+	}
+	if first.message.Format != "org.matrix.custom.html" || !strings.Contains(first.message.FormattedBody, "<strong>app</strong>") {
+		t.Errorf("expected HTML formatted body, got %q", first.message.FormattedBody)
+	}
+
+	// each notification has its own transaction
+	if err := mn.SendNotification(report); err != nil {
+		t.Fatal(err)
+	}
+	if second := receiveMatrixRequest(t, requests); second.path == first.path {
+		t.Errorf("expected a new transaction ID, got %s again", second.path)
+	}
+}
+
+func TestMatrixRetryReusesTxnID(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  bool
+		attempts int
+	}{
+		{"server error", []int{http.StatusBadGateway}, false, 2},
+		{"rate limited", []int{http.StatusTooManyRequests, http.StatusInternalServerError}, false, 3},
+		{"gives up", []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError}, false, matrixMaxAttempts},
+		{"client error", []int{http.StatusForbidden}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newTestMatrixServer(t, tt.statuses...)
+			mn := newTestMatrixNotifier(t, server.URL)
+			err := mn.SendNotification(&general.Report{Severity: general.SeverityLevelCritical})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %t, got %v", tt.wantErr, err)
+			}
+			path := receiveMatrixRequest(t, requests).path
+			for i := 1; i < tt.attempts; i++ {
+				if retried := receiveMatrixRequest(t, requests).path; retried != path {
+					t.Errorf("expected the retry to reuse %s, got %s", path, retried)
+				}
+			}
+			select {
+			case <-requests:
+				t.Errorf("expected %d attempts only", tt.attempts)
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
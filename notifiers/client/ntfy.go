@@ -0,0 +1,148 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/notifiers/common"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// dfltNtfyPriorities uses ntfy priorities 1 (min) - 5 (max)
+	dfltNtfyPriorities = map[general.SeverityLevel]int{
+		general.SeverityLevelRecovery: 2,
+		general.SeverityLevelInfo:     3,
+		general.SeverityLevelWarning:  4,
+		general.SeverityLevelCritical: 5,
+	}
+
+	// ntfyTags are rendered as emojis by ntfy clients
+	ntfyTags = map[general.SeverityLevel]string{
+		general.SeverityLevelRecovery: "white_check_mark",
+		general.SeverityLevelInfo:     "information_source",
+		general.SeverityLevelWarning:  "warning",
+		general.SeverityLevelCritical: "rotating_light",
+	}
+)
+
+type NtfyNotifierArgs struct {
+	Server string `json:"server"`
+	Topic  string `json:"topic"`
+
+	// Token or Username and Password are used for authentication
+	// (if required by the server)
+	Token    string `json:"token"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+
+	// Priorities override default mapping of severity levels
+	// to ntfy priorities (1 - 5)
+	Priorities map[general.SeverityLevel]int `json:"priorities"`
+}
+
+type ntfyMessage struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority"`
+	Tags     []string `json:"tags"`
+	Click    string   `json:"click,omitempty"`
+	Markdown bool     `json:"markdown"`
+}
+
+type ntfyNotifier struct {
+	name       string
+	info       general.GeneralInfo
+	args       *NtfyNotifierArgs
+	filter     common.FilterConf
+	priorities map[general.SeverityLevel]int
+	headers    map[string]string
+	client     *http.Client
+}
+
+func (nn *ntfyNotifier) ShouldBeSent(report *general.Report) bool {
+	return nn.filter.IsFiltered(report)
+}
+
+func (nn *ntfyNotifier) SendNotification(report *general.Report) error {
+	tags := []string{ntfyTags[report.Severity]}
+	if report.Escalated {
+		tags = append(tags, "fire")
+	}
+	if report.Reminder > 0 {
+		tags = append(tags, "alarm_clock")
+	}
+	err := postPushMessage(nn.client, nn.info, nn.args.Server, nn.headers, &ntfyMessage{
+		Topic:    nn.args.Topic,
+		Title:    pushTitle(report),
+		Message:  pushMessage(report),
+		Priority: nn.priorities[report.Severity],
+		Tags:     tags,
+		Click:    pushClickURL(nn.info, report),
+		Markdown: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send ntfy notification: %w", err)
+	}
+	return nil
+}
+
+func NewNtfyNotifier(
+	conf *common.NotifierConf,
+	info general.GeneralInfo,
+	args *NtfyNotifierArgs,
+) (common.Notifier, error) {
+	if _, err := url.ParseRequestURI(args.Server); err != nil {
+		return nil, fmt.Errorf("invalid ntfy server: %w", err)
+	}
+	if args.Topic == "" {
+		return nil, errors.New("ntfy topic not set")
+	}
+	if args.Token != "" && args.Username != "" {
+		return nil, errors.New("ntfy token and username cannot be used together")
+	}
+	priorities, err := pushPriorities(dfltNtfyPriorities, args.Priorities, 1, 5)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ntfy priorities: %w", err)
+	}
+	headers := make(map[string]string)
+	if args.Token != "" {
+		headers["Authorization"] = "Bearer " + args.Token
+	}
+	if args.Username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(args.Username + ":" + args.Password))
+		headers["Authorization"] = "Basic " + credentials
+	}
+	log.Info().Msgf("creating ntfy notifier `%s` for topic `%s`", conf.Name, args.Topic)
+	notifier := &ntfyNotifier{
+		name:       conf.Name,
+		info:       info,
+		args:       args,
+		filter:     conf.Filter,
+		priorities: priorities,
+		headers:    headers,
+		client:     &http.Client{Timeout: pushTimeout},
+	}
+	return notifier, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/templates"
+	"github.com/rs/zerolog/log"
+)
+
+const pushTimeout = 10 * time.Second
+
+// pushPriorities maps severity levels to priorities of a push service.
+// Configured values override the defaults.
+func pushPriorities(
+	dflt map[general.SeverityLevel]int,
+	configured map[general.SeverityLevel]int,
+	minPriority, maxPriority int,
+) (map[general.SeverityLevel]int, error) {
+	ans := make(map[general.SeverityLevel]int, len(dflt))
+	for severity, priority := range dflt {
+		ans[severity] = priority
+	}
+	for severity, priority := range configured {
+		if err := severity.Validate(); err != nil {
+			return nil, err
+		}
+		if priority < minPriority || priority > maxPriority {
+			return nil, fmt.Errorf("priority of %s must be between %d and %d", severity, minPriority, maxPriority)
+		}
+		ans[severity] = priority
+	}
+	return ans, nil
+}
+
+// pushTitle provides a title of a push notification
+func pushTitle(report *general.Report) string {
+	if report.Reminder > 0 {
+		return fmt.Sprintf("[REMINDER %d] %s", report.Reminder, report.Subject)
+	}
+	return report.Subject
+}
+
+// pushMessage provides a (Markdown) text of a push notification
+func pushMessage(report *general.Report) string {
+	message := strings.ToUpper(report.Severity.String())
+	if report.Escalated {
+		message = "ESCALATED " + message
+	}
+	message += " - " + templates.MkReportSourceIDLabel(*report)
+	if report.Body != "" {
+		message += "\n\n" + report.Body
+	}
+	return message
+}
+
+// pushClickURL provides a link to the report detail
+// (empty string if not available)
+func pushClickURL(info general.GeneralInfo, report *general.Report) string {
+	if info.PublicPath == "" || report.ID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/ui/detail?id=%d", info.PublicPath, report.ID)
+}
+
+// postPushMessage posts JSON encoded message to a push service
+func postPushMessage(
+	client *http.Client,
+	info general.GeneralInfo,
+	pushURL string,
+	headers map[string]string,
+	message any,
+) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", pushURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("CNKNotifier/%s-%s", info.Build.Version, info.Build.GitCommit))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server responded with %d: %s", resp.StatusCode, body)
+	}
+
+	log.Debug().Bytes("response", body).Msg("performed push notification post")
+	return nil
+}
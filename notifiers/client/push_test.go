@@ -0,0 +1,111 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+func TestPushPriorities(t *testing.T) {
+	dflt := map[general.SeverityLevel]int{
+		general.SeverityLevelInfo:     2,
+		general.SeverityLevelWarning:  3,
+		general.SeverityLevelCritical: 5,
+		general.SeverityLevelRecovery: 2,
+	}
+	tests := []struct {
+		name       string
+		configured map[general.SeverityLevel]int
+		want       map[general.SeverityLevel]int
+		wantErr    bool
+	}{
+		{"defaults", nil, dflt, false},
+		{
+			"override",
+			map[general.SeverityLevel]int{general.SeverityLevelWarning: 4},
+			map[general.SeverityLevel]int{
+				general.SeverityLevelInfo:     2,
+				general.SeverityLevelWarning:  4,
+				general.SeverityLevelCritical: 5,
+				general.SeverityLevelRecovery: 2,
+			},
+			false,
+		},
+		{
+			"bounds",
+			map[general.SeverityLevel]int{general.SeverityLevelInfo: 1, general.SeverityLevelCritical: 5},
+			map[general.SeverityLevel]int{
+				general.SeverityLevelInfo:     1,
+				general.SeverityLevelWarning:  3,
+				general.SeverityLevelCritical: 5,
+				general.SeverityLevelRecovery: 2,
+			},
+			false,
+		},
+		{"below minimum", map[general.SeverityLevel]int{general.SeverityLevelInfo: 0}, nil, true},
+		{"above maximum", map[general.SeverityLevel]int{general.SeverityLevelCritical: 6}, nil, true},
+		{"invalid severity", map[general.SeverityLevel]int{"fatal": 5}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pushPriorities(dflt, tt.configured, 1, 5)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %t, got %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+	if dflt[general.SeverityLevelWarning] != 3 {
+		t.Error("expected the defaults to remain unchanged")
+	}
+}
+
+func TestPushMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		report general.Report
+		want   string
+	}{
+		{
+			"plain",
+			general.Report{SourceID: general.SourceID{App: "app"}, Severity: general.SeverityLevelWarning, Body: "body"},
+			"WARNING - app\n\nbody",
+		},
+		{
+			"full source without body",
+			general.Report{SourceID: general.SourceID{App: "app", Instance: "a", Tag: "t"}, Severity: general.SeverityLevelInfo},
+			"INFO - app/a[t]",
+		},
+		{
+			"escalated",
+			general.Report{SourceID: general.SourceID{App: "app"}, Severity: general.SeverityLevelCritical, Body: "body", Escalated: true},
+			"ESCALATED CRITICAL - app\n\nbody",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pushMessage(&tt.report); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
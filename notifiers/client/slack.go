@@ -0,0 +1,282 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/notifiers/common"
+	"github.com/czcorpus/conomi/templates"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	slackTimeout = 10 * time.Second
+
+	// slackMaxTextLength is the Block Kit limit for a section text
+	slackMaxTextLength = 3000
+)
+
+// SlackNotifierArgs configures a Slack incoming webhook.
+// Mattermost and Rocket.Chat incoming webhooks accept the same format.
+type SlackNotifierArgs struct {
+	WebhookURL string `json:"webhookUrl"`
+
+	// Channel and Username override defaults of the webhook
+	// (if supported by the server)
+	Channel  string `json:"channel"`
+	Username string `json:"username"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackElement struct {
+	Type  string     `json:"type"`
+	Text  *slackText `json:"text,omitempty"`
+	URL   string     `json:"url,omitempty"`
+	Style string     `json:"style,omitempty"`
+}
+
+// slackBlock is a layout block. Elements are either slackElement
+// (actions) or slackText (context).
+type slackBlock struct {
+	Type     string     `json:"type"`
+	Text     *slackText `json:"text,omitempty"`
+	Elements []any      `json:"elements,omitempty"`
+}
+
+// slackAttachment contains both Block Kit blocks and legacy fields
+// as Mattermost and Rocket.Chat do not support Block Kit
+type slackAttachment struct {
+	Color     string       `json:"color"`
+	Fallback  string       `json:"fallback"`
+	Title     string       `json:"title"`
+	TitleLink string       `json:"title_link,omitempty"`
+	Text      string       `json:"text"`
+	Footer    string       `json:"footer"`
+	Blocks    []slackBlock `json:"blocks"`
+}
+
+type slackMessage struct {
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+func slackSeverityColor(severity general.SeverityLevel) string {
+	switch severity {
+	case general.SeverityLevelInfo:
+		return "#2f7bbf"
+	case general.SeverityLevelWarning:
+		return "#f2a600"
+	case general.SeverityLevelCritical:
+		return "#d40000"
+	case general.SeverityLevelRecovery:
+		return "#2eb67d"
+	}
+	return "#808080"
+}
+
+// slackEscape escapes control characters of Slack message formatting
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// slackEscapeTruncated escapes the text and truncates it so the result
+// fits within maxLength characters (escaped characters are never split)
+func slackEscapeTruncated(text string, maxLength int) string {
+	escaped := slackEscape(text)
+	if utf8.RuneCountInString(escaped) <= maxLength {
+		return escaped
+	}
+	var ans strings.Builder
+	length := 1 // the ellipsis
+	for _, r := range text {
+		chunk := slackEscape(string(r))
+		length += utf8.RuneCountInString(chunk)
+		if length > maxLength {
+			break
+		}
+		ans.WriteString(chunk)
+	}
+	return ans.String() + "…"
+}
+
+func truncateText(text string, maxLength int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLength {
+		return text
+	}
+	return string(runes[:maxLength-1]) + "…"
+}
+
+type slackNotifier struct {
+	name   string
+	info   general.GeneralInfo
+	args   *SlackNotifierArgs
+	filter common.FilterConf
+	client *http.Client
+}
+
+func (sn *slackNotifier) ShouldBeSent(report *general.Report) bool {
+	return sn.filter.IsFiltered(report)
+}
+
+func (sn *slackNotifier) message(report *general.Report) *slackMessage {
+	title := strings.ToUpper(report.Severity.String()) + ": " + report.Subject
+	if report.Escalated {
+		title = "[ESCALATED] " + title
+	}
+	if report.Reminder > 0 {
+		title = fmt.Sprintf("[REMINDER %d] %s", report.Reminder, title)
+	}
+	source := slackEscape(templates.MkReportSourceIDLabel(*report))
+	body := slackEscapeTruncated(report.Body, slackMaxTextLength)
+	blocks := []slackBlock{
+		{
+			Type: "section",
+			Text: &slackText{
+				Type: "mrkdwn",
+				Text: fmt.Sprintf("*%s*\n%s", slackEscape(title), source),
+			},
+		},
+	}
+	if body != "" {
+		blocks = append(blocks, slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: body},
+		})
+	}
+	var detailURL string
+	if sn.info.PublicPath != "" && report.ID > 0 {
+		detailURL = fmt.Sprintf("%s/ui/detail?id=%d", sn.info.PublicPath, report.ID)
+		listParams := url.Values{}
+		listParams.Set("app", report.SourceID.App)
+		listParams.Set("instance", report.SourceID.Instance)
+		listParams.Set("tag", report.SourceID.Tag)
+		blocks = append(blocks, slackBlock{
+			Type: "actions",
+			Elements: []any{
+				slackElement{
+					Type:  "button",
+					Text:  &slackText{Type: "plain_text", Text: "Inspect report"},
+					URL:   detailURL,
+					Style: "primary",
+				},
+				slackElement{
+					Type: "button",
+					Text: &slackText{Type: "plain_text", Text: "List group"},
+					URL:  sn.info.PublicPath + "/ui/list?" + listParams.Encode(),
+				},
+			},
+		})
+	}
+	footer := "Generated by " + slackEscape(sn.name) + "/Conomi"
+	if sn.info.Build.Version != "" {
+		footer += " " + sn.info.Build.Version
+	}
+	blocks = append(blocks, slackBlock{
+		Type:     "context",
+		Elements: []any{slackText{Type: "mrkdwn", Text: footer}},
+	})
+	text := source
+	if body != "" {
+		text += "\n" + body
+	}
+	return &slackMessage{
+		Channel:  sn.args.Channel,
+		Username: sn.args.Username,
+		Text:     slackEscape(title),
+		Attachments: []slackAttachment{
+			{
+				Color:     slackSeverityColor(report.Severity),
+				Fallback:  slackEscape(title),
+				Title:     slackEscape(title),
+				TitleLink: detailURL,
+				Text:      text,
+				Footer:    footer,
+				Blocks:    blocks,
+			},
+		},
+	}
+}
+
+func (sn *slackNotifier) SendNotification(report *general.Report) error {
+	payload, err := json.Marshal(sn.message(report))
+	if err != nil {
+		return fmt.Errorf("failed to send Slack notification: %w", err)
+	}
+	req, err := http.NewRequest("POST", sn.args.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to send Slack notification: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("CNKNotifier/%s-%s", sn.info.Build.Version, sn.info.Build.GitCommit))
+
+	resp, err := sn.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Slack notification: %w", err)
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to send Slack notification: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to send Slack notification: server responded with %d: %s", resp.StatusCode, body)
+	}
+
+	log.Debug().Bytes("response", body).Msg("performed slack post")
+	return nil
+}
+
+func NewSlackNotifier(
+	conf *common.NotifierConf,
+	info general.GeneralInfo,
+	args *SlackNotifierArgs,
+) (common.Notifier, error) {
+	if args.WebhookURL == "" {
+		return nil, errors.New("slack webhookUrl not set")
+	}
+	if _, err := url.Parse(args.WebhookURL); err != nil {
+		return nil, fmt.Errorf("invalid slack webhookUrl: %w", err)
+	}
+	log.Info().Msgf("creating slack notifier `%s` for channel `%s`", conf.Name, args.Channel)
+	notifier := &slackNotifier{
+		name:   conf.Name,
+		info:   info,
+		args:   args,
+		filter: conf.Filter,
+		client: &http.Client{Timeout: slackTimeout},
+	}
+	return notifier, nil
+}
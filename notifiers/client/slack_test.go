@@ -0,0 +1,131 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/notifiers/common"
+)
+
+func newTestSlackNotifier(t *testing.T, webhookURL string) *slackNotifier {
+	t.Helper()
+	notifier, err := NewSlackNotifier(
+		&common.NotifierConf{Name: "slack"},
+		general.GeneralInfo{PublicPath: "https://conomi.example.com"},
+		&SlackNotifierArgs{WebhookURL: webhookURL},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return notifier.(*slackNotifier)
+}
+
+func TestSlackEscapeTruncated(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		maxLength int
+		want      string
+	}{
+		{"short", "a < b", 10, "a &lt; b"},
+		{"truncated", "abcdef", 4, "abc…"},
+		{"escaped fits", "a&b", 7, "a&amp;b"},
+		// the entity would not fit with the ellipsis so it is left out
+		{"entity not split", "a&b", 6, "a…"},
+		{"multibyte", "žžžž", 3, "žž…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slackEscapeTruncated(tt.text, tt.maxLength); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSlackSeverityColor(t *testing.T) {
+	tests := []struct {
+		severity general.SeverityLevel
+		want     string
+	}{
+		{general.SeverityLevelInfo, "#2f7bbf"},
+		{general.SeverityLevelWarning, "#f2a600"},
+		{general.SeverityLevelCritical, "#d40000"},
+		{general.SeverityLevelRecovery, "#2eb67d"},
+		{"unknown", "#808080"},
+	}
+	sn := newTestSlackNotifier(t, "https://hooks.example.com/x")
+	for _, tt := range tests {
+		t.Run(string(tt.severity), func(t *testing.T) {
+			message := sn.message(&general.Report{Severity: tt.severity})
+			if got := message.Attachments[0].Color; got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSlackMessage(t *testing.T) {
+	var received slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+	sn := newTestSlackNotifier(t, server.URL)
+	report := &general.Report{
+		ID:        7,
+		SourceID:  general.SourceID{App: "app", Instance: "a"},
+		Severity:  general.SeverityLevelCritical,
+		Subject:   "<b> & co",
+		Body:      strings.Repeat("<", slackMaxTextLength),
+		Escalated: true,
+	}
+	if err := sn.SendNotification(report); err != nil {
+		t.Fatal(err)
+	}
+
+	title := "[ESCALATED] CRITICAL: &lt;b&gt; &amp; co"
+	if received.Text != title {
+		t.Errorf("expected text %q, got %q", title, received.Text)
+	}
+	attachment := received.Attachments[0]
+	// legacy fields are needed by servers without Block Kit support
+	if attachment.Title != title || attachment.TitleLink != "https://conomi.example.com/ui/detail?id=7" {
+		t.Errorf("unexpected title %q linking to %q", attachment.Title, attachment.TitleLink)
+	}
+	if !strings.HasPrefix(attachment.Text, "app/a\n&lt;") {
+		t.Errorf("expected source and body in attachment text, got %q", attachment.Text[:20])
+	}
+	body := attachment.Blocks[1].Text.Text
+	if length := utf8.RuneCountInString(body); length > slackMaxTextLength {
+		t.Errorf("expected at most %d characters, got %d", slackMaxTextLength, length)
+	}
+	if !strings.HasSuffix(body, "&lt;…") {
+		t.Errorf("expected body truncated after a whole entity, got %q", body[len(body)-10:])
+	}
+}
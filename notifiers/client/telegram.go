@@ -0,0 +1,245 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf16"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/notifiers/common"
+	"github.com/czcorpus/conomi/templates"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	dfltTelegramServer    = "https://api.telegram.org"
+	dfltTelegramTemplate  = "telegram.gtpl"
+	dfltTelegramParseMode = "MarkdownV2"
+	telegramTimeout       = 10 * time.Second
+
+	// telegramMaxMessageLength is the Bot API limit (in UTF-16 code units).
+	// It applies to texts with markup already parsed so measuring rendered
+	// messages is safe.
+	telegramMaxMessageLength = 4096
+)
+
+type TelegramNotifierArgs struct {
+	// Server allows using a local Bot API server
+	Server string `json:"server"`
+	Token  string `json:"token"`
+
+	// ChatIDs are either numeric IDs or `@channelusername`
+	// (numeric IDs must be written as strings too)
+	ChatIDs []string `json:"chatIds"`
+
+	// ParseMode is either `MarkdownV2` (default) or `HTML`. The template
+	// must escape values accordingly (the `escapeMarkdownV2` function for
+	// MarkdownV2, the `html` function for HTML). As the default template
+	// is written for MarkdownV2, `HTML` requires a custom template.
+	ParseMode string `json:"parseMode"`
+
+	// Template specifies a file within the notifier's tplDirPath
+	// (`telegram.gtpl` by default)
+	Template string `json:"template"`
+}
+
+type telegramMessage struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+type telegramNotifier struct {
+	name   string
+	info   general.GeneralInfo
+	args   *TelegramNotifierArgs
+	filter common.FilterConf
+	tmpl   *template.Template
+	client *http.Client
+}
+
+func (tn *telegramNotifier) ShouldBeSent(report *general.Report) bool {
+	return tn.filter.IsFiltered(report)
+}
+
+func (tn *telegramNotifier) send(message *telegramMessage) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	sendURL, err := url.JoinPath(tn.args.Server, "bot"+tn.args.Token, "sendMessage")
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", sendURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("CNKNotifier/%s-%s", tn.info.Build.Version, tn.info.Build.GitCommit))
+
+	resp, err := tn.client.Do(req)
+	if err != nil {
+		// the URL contains the token so it must not be logged
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server responded with %d: %s", resp.StatusCode, body)
+	}
+
+	log.Debug().Bytes("response", body).Msg("performed telegram post")
+	return nil
+}
+
+// render renders the report message. The report body is truncated
+// so the message fits within the Bot API limit.
+func (tn *telegramNotifier) render(report *general.Report) (string, error) {
+	data := templates.NotificationTemplateData{
+		NotifierName: tn.name,
+		Report:       *report,
+		Info:         tn.info,
+		Reminder:     report.Reminder > 0,
+	}
+	for {
+		var message strings.Builder
+		if err := tn.tmpl.Execute(&message, data); err != nil {
+			return "", err
+		}
+		excess := utf16Length(message.String()) - telegramMaxMessageLength
+		if excess <= 0 {
+			return message.String(), nil
+		}
+		if data.Report.Body == "" {
+			return "", fmt.Errorf("message exceeds %d characters", telegramMaxMessageLength)
+		}
+		// each code unit of the body takes at least one code unit
+		// of the message (more if escaped)
+		data.Report.Body = truncateUTF16(data.Report.Body, utf16Length(data.Report.Body)-excess)
+	}
+}
+
+// utf16Length provides the length of the text in UTF-16 code units
+func utf16Length(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
+
+// truncateUTF16 truncates the text (marking it with an ellipsis) so it
+// fits within maxLength UTF-16 code units. Characters are never split.
+func truncateUTF16(text string, maxLength int) string {
+	if utf16Length(text) <= maxLength {
+		return text
+	}
+	length := 1 // the ellipsis
+	for i, r := range text {
+		length++
+		if r >= 0x10000 {
+			length++ // a surrogate pair
+		}
+		if length > maxLength {
+			if i == 0 {
+				return ""
+			}
+			return text[:i] + "…"
+		}
+	}
+	return text
+}
+
+func (tn *telegramNotifier) SendNotification(report *general.Report) error {
+	message, err := tn.render(report)
+	if err != nil {
+		return fmt.Errorf("failed to send Telegram notification: %w", err)
+	}
+	for _, chatID := range tn.args.ChatIDs {
+		err := tn.send(&telegramMessage{
+			ChatID:                chatID,
+			Text:                  message,
+			ParseMode:             tn.args.ParseMode,
+			DisableWebPagePreview: true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to send Telegram notification to %s: %w", chatID, err)
+		}
+	}
+	return nil
+}
+
+func NewTelegramNotifier(
+	conf *common.NotifierConf,
+	info general.GeneralInfo,
+	args *TelegramNotifierArgs,
+) (common.Notifier, error) {
+	if args.Token == "" {
+		return nil, errors.New("telegram token not set")
+	}
+	if len(args.ChatIDs) == 0 {
+		return nil, errors.New("telegram requires at least one chat ID")
+	}
+	if args.Server == "" {
+		args.Server = dfltTelegramServer
+	}
+	switch args.ParseMode {
+	case "":
+		args.ParseMode = dfltTelegramParseMode
+	case "MarkdownV2":
+	case "HTML":
+		if args.Template == "" {
+			return nil, errors.New("telegram parseMode `HTML` requires a custom template, the default one uses MarkdownV2")
+		}
+	default:
+		return nil, fmt.Errorf("unknown telegram parseMode `%s`, use `MarkdownV2` or `HTML`", args.ParseMode)
+	}
+	if args.Template == "" {
+		args.Template = dfltTelegramTemplate
+	}
+	tmpl, err := templates.GetTemplate(filepath.Join(conf.TplDirPath, args.Template))
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Msgf("creating telegram notifier `%s` with chat(s) %v", conf.Name, args.ChatIDs)
+	notifier := &telegramNotifier{
+		name:   conf.Name,
+		info:   info,
+		args:   args,
+		filter: conf.Filter,
+		tmpl:   tmpl,
+		client: &http.Client{Timeout: telegramTimeout},
+	}
+	return notifier, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/notifiers/common"
+)
+
+func newTestTelegramNotifier(t *testing.T, args *TelegramNotifierArgs) (*telegramNotifier, error) {
+	t.Helper()
+	conf := &common.NotifierConf{Name: "telegram", TplDirPath: "../../templates"}
+	notifier, err := NewTelegramNotifier(conf, general.GeneralInfo{PublicPath: "https://conomi.example.com"}, args)
+	if err != nil {
+		return nil, err
+	}
+	return notifier.(*telegramNotifier), nil
+}
+
+func TestTelegramRender(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		truncated bool
+	}{
+		{"short", "a short body", false},
+		{"long", strings.Repeat("a", 5000), true},
+		// every character is escaped so the body doubles when rendered
+		{"escaped", strings.Repeat(".", 3000), true},
+		{"multibyte", strings.Repeat("ž", 5000), true},
+		// astral characters take two UTF-16 code units
+		{"astral", strings.Repeat("🔥", 2100), true},
+		{"astral fitting", strings.Repeat("🔥", 1000), false},
+	}
+	tn, err := newTestTelegramNotifier(t, &TelegramNotifierArgs{Token: "token", ChatIDs: []string{"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := tn.render(&general.Report{
+				ID:       1,
+				SourceID: general.SourceID{App: "app"},
+				Severity: general.SeverityLevelCritical,
+				Subject:  "subject",
+				Body:     tt.body,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if length := len(utf16.Encode([]rune(message))); length > telegramMaxMessageLength {
+				t.Errorf("expected at most %d characters, got %d", telegramMaxMessageLength, length)
+			}
+			if truncated := strings.Contains(message, "…"); truncated != tt.truncated {
+				t.Errorf("expected truncated %t, got %t", tt.truncated, truncated)
+			}
+		})
+	}
+}
+
+func TestTruncateUTF16(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		maxLength int
+		want      string
+	}{
+		{"fits", "abc", 3, "abc"},
+		{"truncated", "abcd", 3, "ab…"},
+		{"pair not split", "a🔥b", 3, "a…"},
+		{"pair fits", "a🔥bc", 4, "a🔥…"},
+		{"nothing fits", "🔥🔥", 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateUTF16(tt.text, tt.maxLength); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewTelegramNotifierParseMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		parseMode string
+		template  string
+		wantErr   bool
+	}{
+		{"default", "", "", false},
+		{"markdown", "MarkdownV2", "", false},
+		{"html with default template", "HTML", "", true},
+		{"html with custom template", "HTML", "telegram.gtpl", false},
+		{"unknown", "Markdown", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestTelegramNotifier(t, &TelegramNotifierArgs{
+				Token:     "token",
+				ChatIDs:   []string{"1"},
+				ParseMode: tt.parseMode,
+				Template:  tt.template,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
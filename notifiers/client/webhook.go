@@ -0,0 +1,235 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/notifiers/common"
+	"github.com/czcorpus/conomi/templates"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	dfltWebhookTimeoutSecs     = 10
+	dfltWebhookSignatureHeader = "X-Conomi-Signature"
+	dfltWebhookContentType     = "application/json"
+)
+
+type WebhookNotifierArgs struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+
+	// Template specifies a file (within the notifier's tplDirPath)
+	// used to render the payload. If empty, the report is sent
+	// as JSON (see webhookPayload). Values are not escaped by default,
+	// JSON templates have to use the `json` function
+	// (e.g. `{"subject": {{ .Report.Subject | json }}}`).
+	Template    string `json:"template"`
+	ContentType string `json:"contentType"`
+
+	// Secret enables HMAC-SHA256 signature of the payload
+	// sent in the SignatureHeader as `sha256=<hex digest>`
+	Secret          string `json:"secret"`
+	SignatureHeader string `json:"signatureHeader"`
+
+	TimeoutSecs int `json:"timeoutSecs"`
+
+	// TLS options - a custom CA certificate, a client certificate
+	// and disabled server certificate verification (for testing only)
+	CAFile             string `json:"caFile"`
+	CertFile           string `json:"certFile"`
+	KeyFile            string `json:"keyFile"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+}
+
+// webhookPayload is the default payload of webhook notifications
+type webhookPayload struct {
+	Notifier  string         `json:"notifier"`
+	Report    general.Report `json:"report"`
+	Reminder  int            `json:"reminder,omitempty"`
+	DetailURL string         `json:"detailUrl,omitempty"`
+}
+
+type webhookNotifier struct {
+	name   string
+	info   general.GeneralInfo
+	args   *WebhookNotifierArgs
+	filter common.FilterConf
+	tmpl   *template.Template
+	client *http.Client
+}
+
+func (wn *webhookNotifier) ShouldBeSent(report *general.Report) bool {
+	return wn.filter.IsFiltered(report)
+}
+
+func (wn *webhookNotifier) payload(report *general.Report) ([]byte, error) {
+	if wn.tmpl != nil {
+		var payload bytes.Buffer
+		err := wn.tmpl.Execute(
+			&payload,
+			templates.NotificationTemplateData{
+				NotifierName: wn.name,
+				Report:       *report,
+				Info:         wn.info,
+				Reminder:     report.Reminder > 0,
+			},
+		)
+		return payload.Bytes(), err
+	}
+	payload := webhookPayload{
+		Notifier: wn.name,
+		Report:   *report,
+		Reminder: report.Reminder,
+	}
+	if wn.info.PublicPath != "" && report.ID > 0 {
+		payload.DetailURL = fmt.Sprintf("%s/ui/detail?id=%d", wn.info.PublicPath, report.ID)
+	}
+	return json.Marshal(payload)
+}
+
+func (wn *webhookNotifier) SendNotification(report *general.Report) error {
+	payload, err := wn.payload(report)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook notification: %w", err)
+	}
+	req, err := http.NewRequest("POST", wn.args.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to send webhook notification: %w", err)
+	}
+	req.Header.Set("Content-Type", wn.args.ContentType)
+	req.Header.Set("User-Agent", fmt.Sprintf("CNKNotifier/%s-%s", wn.info.Build.Version, wn.info.Build.GitCommit))
+	for name, value := range wn.args.Headers {
+		req.Header.Set(name, value)
+	}
+	if wn.args.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(wn.args.Secret))
+		mac.Write(payload)
+		req.Header.Set(wn.args.SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := wn.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook notification: %w", err)
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook notification: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to send webhook notification: server responded with %d: %s", resp.StatusCode, body)
+	}
+
+	log.Debug().Bytes("response", body).Msg("performed webhook post")
+	return nil
+}
+
+func newWebhookTLSConfig(args *WebhookNotifierArgs) (*tls.Config, error) {
+	tlsConf := &tls.Config{InsecureSkipVerify: args.InsecureSkipVerify}
+	if args.CAFile != "" {
+		caCert, err := os.ReadFile(args.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read webhook CA file: %w", err)
+		}
+		tlsConf.RootCAs = x509.NewCertPool()
+		if !tlsConf.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in webhook CA file %s", args.CAFile)
+		}
+	}
+	if args.CertFile != "" || args.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(args.CertFile, args.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load webhook client certificate: %w", err)
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConf, nil
+}
+
+func NewWebhookNotifier(
+	conf *common.NotifierConf,
+	info general.GeneralInfo,
+	args *WebhookNotifierArgs,
+) (common.Notifier, error) {
+	webhookURL, err := url.Parse(args.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if webhookURL.Scheme != "http" && webhookURL.Scheme != "https" {
+		return nil, errors.New("webhook url must use either `http` or `https` scheme")
+	}
+	if args.TimeoutSecs < 0 {
+		return nil, errors.New("webhook timeoutSecs cannot be negative")
+	}
+	if args.TimeoutSecs == 0 {
+		args.TimeoutSecs = dfltWebhookTimeoutSecs
+	}
+	if args.ContentType == "" {
+		args.ContentType = dfltWebhookContentType
+	}
+	if args.SignatureHeader == "" {
+		args.SignatureHeader = dfltWebhookSignatureHeader
+	}
+	var tmpl *template.Template
+	if args.Template != "" {
+		tmpl, err = templates.GetTemplate(filepath.Join(conf.TplDirPath, args.Template))
+		if err != nil {
+			return nil, err
+		}
+	}
+	tlsConf, err := newWebhookTLSConfig(args)
+	if err != nil {
+		return nil, err
+	}
+	if args.InsecureSkipVerify {
+		log.Warn().Msgf("webhook notifier `%s` does not verify server certificates", conf.Name)
+	}
+	log.Info().Msgf("creating webhook notifier `%s` with url %s", conf.Name, webhookURL.Redacted())
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConf
+	notifier := &webhookNotifier{
+		name:   conf.Name,
+		info:   info,
+		args:   args,
+		filter: conf.Filter,
+		tmpl:   tmpl,
+		client: &http.Client{
+			Timeout:   time.Duration(args.TimeoutSecs) * time.Second,
+			Transport: transport,
+		},
+	}
+	return notifier, nil
+}
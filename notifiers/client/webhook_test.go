@@ -0,0 +1,152 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/notifiers/common"
+)
+
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newTestWebhookServer(t *testing.T) (*httptest.Server, *webhookRequest) {
+	t.Helper()
+	received := &webhookRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.header = r.Header
+		received.body, _ = io.ReadAll(r.Body)
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+var testWebhookReport = &general.Report{
+	ID:       7,
+	SourceID: general.SourceID{App: "app"},
+	Severity: general.SeverityLevelCritical,
+	Subject:  "a \"quoted\" subject",
+	Body:     "first line\nsecond line",
+	Reminder: 2,
+}
+
+func TestWebhookDefaultPayload(t *testing.T) {
+	server, received := newTestWebhookServer(t)
+	notifier, err := NewWebhookNotifier(
+		&common.NotifierConf{Name: "hook"},
+		general.GeneralInfo{PublicPath: "https://conomi.example.com"},
+		&WebhookNotifierArgs{
+			URL:     server.URL,
+			Headers: map[string]string{"Authorization": "Bearer abc", "X-Custom": "value"},
+			Secret:  "secret",
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.SendNotification(testWebhookReport); err != nil {
+		t.Fatal(err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(received.body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); received.header.Get(dfltWebhookSignatureHeader) != want {
+		t.Errorf("expected signature %s, got %s", want, received.header.Get(dfltWebhookSignatureHeader))
+	}
+	for name, value := range map[string]string{
+		"Authorization": "Bearer abc",
+		"X-Custom":      "value",
+		"Content-Type":  dfltWebhookContentType,
+	} {
+		if got := received.header.Get(name); got != value {
+			t.Errorf("expected header %s: %s, got %s", name, value, got)
+		}
+	}
+	var payload webhookPayload
+	if err := json.Unmarshal(received.body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Notifier != "hook" || payload.Reminder != 2 || payload.Report.Subject != testWebhookReport.Subject ||
+		payload.DetailURL != "https://conomi.example.com/ui/detail?id=7" {
+		t.Errorf("unexpected payload %s", received.body)
+	}
+}
+
+func TestWebhookTemplatePayload(t *testing.T) {
+	server, received := newTestWebhookServer(t)
+	tplDir := t.TempDir()
+	tpl := `{"subject": {{ .Report.Subject | json }}, "body": {{ .Report.Body | json }}, "reminder": {{ .Reminder | json }}}`
+	if err := os.WriteFile(filepath.Join(tplDir, "hook.gtpl"), []byte(tpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	notifier, err := NewWebhookNotifier(
+		&common.NotifierConf{Name: "hook", TplDirPath: tplDir},
+		general.GeneralInfo{},
+		&WebhookNotifierArgs{URL: server.URL, Template: "hook.gtpl"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.SendNotification(testWebhookReport); err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Subject  string `json:"subject"`
+		Body     string `json:"body"`
+		Reminder bool   `json:"reminder"`
+	}
+	if err := json.Unmarshal(received.body, &payload); err != nil {
+		t.Fatalf("invalid payload %s: %s", received.body, err)
+	}
+	if payload.Subject != testWebhookReport.Subject || payload.Body != testWebhookReport.Body || !payload.Reminder {
+		t.Errorf("unexpected payload %s", received.body)
+	}
+}
+
+func TestNewWebhookNotifierURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://example.com/hook", false},
+		{"http://localhost:8080/hook", false},
+		{"ftp://example.com/hook", true},
+		{"example.com/hook", true},
+		{"://example.com", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			_, err := NewWebhookNotifier(&common.NotifierConf{Name: "hook"}, general.GeneralInfo{}, &WebhookNotifierArgs{URL: tt.url})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
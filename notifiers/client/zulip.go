@@ -72,6 +72,7 @@ func (zn *zulipNotifier) SendNotification(report *general.Report) error {
 			NotifierName: zn.name,
 			Report:       *report,
 			Info:         zn.info,
+			Reminder:     report.Reminder > 0,
 		},
 	); err != nil {
 		return fmt.Errorf("failed to send Zulip notification: %w", err)
@@ -28,6 +28,12 @@ type NotifierConf struct {
 	Args       map[string]any `json:"args"`
 	Filter     FilterConf     `json:"filter"`
 	TplDirPath string         `json:"tplDirPath"`
+
+	// Assignee makes the notifier personal - it is used only
+	// for escalation notifications of groups assigned to the user
+	// (as identified by the user name), in addition to the shared
+	// or escalation chain notifiers
+	Assignee string `json:"assignee"`
 }
 
 type FilterConf struct {
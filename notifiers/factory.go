@@ -17,7 +17,9 @@
 package notifiers
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/czcorpus/cnc-gokit/mail"
@@ -25,6 +27,7 @@ import (
 	"github.com/czcorpus/conomi/notifiers/client"
 	"github.com/czcorpus/conomi/notifiers/common"
 	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog/log"
 )
 
 func clientsFactory(
@@ -55,6 +58,66 @@ func clientsFactory(
 			if err != nil {
 				return nil, err
 			}
+		case "matrix":
+			var matrixConf client.MatrixNotifierArgs
+			err := mapstructure.Decode(conf.Args, &matrixConf)
+			if err != nil {
+				return nil, fmt.Errorf("invalid matrix notifier conf: %s", err)
+			}
+			clients[i], err = client.NewMatrixNotifier(&conf, info, &matrixConf)
+			if err != nil {
+				return nil, err
+			}
+		case "telegram":
+			var telegramConf client.TelegramNotifierArgs
+			err := mapstructure.Decode(conf.Args, &telegramConf)
+			if err != nil {
+				return nil, fmt.Errorf("invalid telegram notifier conf: %s", err)
+			}
+			clients[i], err = client.NewTelegramNotifier(&conf, info, &telegramConf)
+			if err != nil {
+				return nil, err
+			}
+		case "ntfy":
+			var ntfyConf client.NtfyNotifierArgs
+			err := mapstructure.Decode(conf.Args, &ntfyConf)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ntfy notifier conf: %s", err)
+			}
+			clients[i], err = client.NewNtfyNotifier(&conf, info, &ntfyConf)
+			if err != nil {
+				return nil, err
+			}
+		case "gotify":
+			var gotifyConf client.GotifyNotifierArgs
+			err := mapstructure.Decode(conf.Args, &gotifyConf)
+			if err != nil {
+				return nil, fmt.Errorf("invalid gotify notifier conf: %s", err)
+			}
+			clients[i], err = client.NewGotifyNotifier(&conf, info, &gotifyConf)
+			if err != nil {
+				return nil, err
+			}
+		case "slack":
+			var slackConf client.SlackNotifierArgs
+			err := mapstructure.Decode(conf.Args, &slackConf)
+			if err != nil {
+				return nil, fmt.Errorf("invalid slack notifier conf: %s", err)
+			}
+			clients[i], err = client.NewSlackNotifier(&conf, info, &slackConf)
+			if err != nil {
+				return nil, err
+			}
+		case "webhook":
+			var webhookConf client.WebhookNotifierArgs
+			err := mapstructure.Decode(conf.Args, &webhookConf)
+			if err != nil {
+				return nil, fmt.Errorf("invalid webhook notifier conf: %s", err)
+			}
+			clients[i], err = client.NewWebhookNotifier(&conf, info, &webhookConf)
+			if err != nil {
+				return nil, err
+			}
 		default:
 			return nil, fmt.Errorf("unknown notifier type %s", conf.Type)
 		}
@@ -62,25 +125,91 @@ func clientsFactory(
 	return clients, nil
 }
 
+// Snoozer tells whether notifications related to a report
+// are temporarily silenced
+type Snoozer interface {
+	IsSnoozed(report *general.Report) bool
+}
+
 type Notifiers struct {
 	notifiers []common.Notifier
+	names     []string
+	assignees []string
+	snoozer   Snoozer
 }
 
-func (n *Notifiers) SendNotifications(report *general.Report) error {
-	for _, client := range n.notifiers {
+func (n *Notifiers) SetSnoozer(snoozer Snoozer) {
+	n.snoozer = snoozer
+}
+
+// send sends the report via notifiers accepted by the `accept` function
+// (which obtains the index of a notifier)
+func (n *Notifiers) send(accept func(i int) bool, report *general.Report) error {
+	if report.InMaintenance {
+		return nil
+	}
+	if n.snoozer != nil && n.snoozer.IsSnoozed(report) {
+		log.Debug().
+			Str("app", report.SourceID.App).
+			Str("instance", report.SourceID.Instance).
+			Str("tag", report.SourceID.Tag).
+			Msg("notification snoozed")
+		return nil
+	}
+	// a failing notifier must not prevent the other ones from sending
+	var errs []error
+	for i, client := range n.notifiers {
+		if !accept(i) {
+			continue
+		}
 		if client.ShouldBeSent(report) {
 			if err := client.SendNotification(report); err != nil {
-				return fmt.Errorf("failed to send notifications: %w", err)
+				errs = append(errs, fmt.Errorf("notifier %s: %w", n.names[i], err))
 			}
 		}
 	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to send notifications: %w", err)
+	}
 	return nil
 }
 
+// isPersonal tells whether the i-th notifier is a personal
+// notifier of the assignee
+func (n *Notifiers) isPersonal(i int, assignee string) bool {
+	return assignee != "" && n.assignees[i] == assignee
+}
+
+// SendNotifications sends the report via all the shared
+// (i.e. not personal) notifiers
+func (n *Notifiers) SendNotifications(report *general.Report) error {
+	return n.send(func(i int) bool { return n.assignees[i] == "" }, report)
+}
+
+// SendAssigneeNotifications sends the report via all the shared
+// notifiers and, on top of that, via personal notifiers of the assignee
+// (if any)
+func (n *Notifiers) SendAssigneeNotifications(assignee string, report *general.Report) error {
+	return n.send(func(i int) bool { return n.assignees[i] == "" || n.isPersonal(i, assignee) }, report)
+}
+
+// SendNamedNotifications sends the report via notifiers with the
+// specified names (regardless of whether they are personal or not)
+// and, on top of that, via personal notifiers of the assignee (if any)
+func (n *Notifiers) SendNamedNotifications(names []string, assignee string, report *general.Report) error {
+	return n.send(func(i int) bool { return slices.Contains(names, n.names[i]) || n.isPersonal(i, assignee) }, report)
+}
+
 func NewNotifiers(info general.GeneralInfo, notifiersConf []common.NotifierConf, loc *time.Location) (*Notifiers, error) {
 	clients, err := clientsFactory(info, notifiersConf, loc)
 	if err != nil {
 		return nil, err
 	}
-	return &Notifiers{notifiers: clients}, nil
+	names := make([]string, len(notifiersConf))
+	assignees := make([]string, len(notifiersConf))
+	for i, conf := range notifiersConf {
+		names[i] = conf.Name
+		assignees[i] = conf.Assignee
+	}
+	return &Notifiers{notifiers: clients, names: names, assignees: assignees}, nil
 }
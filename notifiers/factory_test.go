@@ -0,0 +1,126 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifiers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+type recordingNotifier struct {
+	name string
+	sent *[]string
+	err  error
+}
+
+func (rn *recordingNotifier) ShouldBeSent(report *general.Report) bool {
+	return true
+}
+
+func (rn *recordingNotifier) SendNotification(report *general.Report) error {
+	*rn.sent = append(*rn.sent, rn.name)
+	return rn.err
+}
+
+func newTestNotifiers(sent *[]string) *Notifiers {
+	ans := &Notifiers{
+		names:     []string{"shared", "pager", "jdoe-mail", "other-mail"},
+		assignees: []string{"", "", "jdoe", "other"},
+	}
+	for _, name := range ans.names {
+		ans.notifiers = append(ans.notifiers, &recordingNotifier{name: name, sent: sent})
+	}
+	return ans
+}
+
+func TestNotifiersRouting(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(n *Notifiers, report *general.Report) error
+		want []string
+	}{
+		{
+			"shared",
+			func(n *Notifiers, report *general.Report) error { return n.SendNotifications(report) },
+			[]string{"shared", "pager"},
+		},
+		{
+			"assignee on top of shared",
+			func(n *Notifiers, report *general.Report) error { return n.SendAssigneeNotifications("jdoe", report) },
+			[]string{"shared", "pager", "jdoe-mail"},
+		},
+		{
+			"no assignee",
+			func(n *Notifiers, report *general.Report) error { return n.SendAssigneeNotifications("", report) },
+			[]string{"shared", "pager"},
+		},
+		{
+			"assignee on top of named",
+			func(n *Notifiers, report *general.Report) error {
+				return n.SendNamedNotifications([]string{"pager"}, "jdoe", report)
+			},
+			[]string{"pager", "jdoe-mail"},
+		},
+		{
+			"named personal",
+			func(n *Notifiers, report *general.Report) error {
+				return n.SendNamedNotifications([]string{"other-mail"}, "", report)
+			},
+			[]string{"other-mail"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			if err := tt.send(newTestNotifiers(&sent), &general.Report{}); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sent, tt.want) {
+				t.Errorf("sent via %v, want %v", sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifiersSkipMaintenance(t *testing.T) {
+	var sent []string
+	if err := newTestNotifiers(&sent).SendAssigneeNotifications("jdoe", &general.Report{InMaintenance: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) > 0 {
+		t.Errorf("expected no notifications during maintenance, got %v", sent)
+	}
+}
+
+func TestNotifiersContinueOnError(t *testing.T) {
+	var sent []string
+	notifiers := newTestNotifiers(&sent)
+	errShared := errors.New("shared failed")
+	errPager := errors.New("pager failed")
+	notifiers.notifiers[0].(*recordingNotifier).err = errShared
+	notifiers.notifiers[1].(*recordingNotifier).err = errPager
+	err := notifiers.SendAssigneeNotifications("jdoe", &general.Report{})
+	if !errors.Is(err, errShared) || !errors.Is(err, errPager) {
+		t.Errorf("expected both notifier errors, got %v", err)
+	}
+	if want := []string{"shared", "pager", "jdoe-mail"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("sent via %v, want %v", sent, want)
+	}
+}
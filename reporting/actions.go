@@ -28,26 +28,37 @@ import (
 	"github.com/czcorpus/conomi/engine"
 	"github.com/czcorpus/conomi/escalator"
 	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/maintenance"
 	"github.com/czcorpus/conomi/notifiers"
 	"github.com/czcorpus/conomi/reporting/content"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	dfltReportsPageSize = 100
+	maxReportsPageSize  = 1000
+
+	// headers of paginated lists of reports
+	totalCountHeader = "X-Total-Count"
+	nextCursorHeader = "X-Next-Cursor"
+)
+
 type Actions struct {
 	loc        *time.Location
-	db         *sql.DB
+	store      engine.ReportStore
 	n          *notifiers.Notifiers
 	e          *escalator.Escalator
+	m          *maintenance.Schedule
 	selfReport chan error
 }
 
-func (a *Actions) autoResolve(ctx *gin.Context, rdb *engine.ReportsDatabase, groupID int) error {
-	userID, err := auth.GetUserID(ctx, rdb)
+func (a *Actions) autoResolve(ctx *gin.Context, groupID int) error {
+	userID, err := auth.GetUserID(ctx, a.store)
 	if err != nil {
 		return fmt.Errorf("failed to auto-resolve a report: %w", err)
 	}
-	if err := rdb.ResolveGroup(groupID, userID); err != nil {
+	if err := a.store.ResolveGroup(groupID, userID); err != nil {
 		return fmt.Errorf("failed to auto-resolve a report: %w", err)
 	}
 	if err := a.e.Reload(); err != nil {
@@ -57,22 +68,31 @@ func (a *Actions) autoResolve(ctx *gin.Context, rdb *engine.ReportsDatabase, gro
 }
 
 func (a *Actions) handleReport(ctx *gin.Context, report *general.Report) error {
-	rdb := engine.NewReportsDatabase(a.db)
-	if err := rdb.InsertReport(report); err != nil {
-		return fmt.Errorf("handleReport failed with insert error: %w", err)
+	report.InMaintenance = a.m.IsActive(report.SourceID, report.Created)
+	// the escalator stores the report so a concurrent reload cannot
+	// miss it or count it twice
+	if err := a.e.InsertReport(report); err != nil {
+		return fmt.Errorf("handleReport failed: %w", err)
 	}
+	// recipients have to be obtained before a possible auto-resolve
+	// (a recovery of an escalated group is escalated as well)
+	assignee, names := a.e.Recipients(report.SourceID)
 
 	// ctx == nil for self self reporting
 	if ctx != nil && report.Severity == general.SeverityLevelRecovery {
-		if err := a.autoResolve(ctx, rdb, report.GroupID); err != nil {
+		if err := a.autoResolve(ctx, report.GroupID); err != nil {
 			// must not be sent in self reporting! (infinite loop)
 			a.selfReport <- fmt.Errorf("handleReport failed with autoResolve error: %w", err)
 			log.Error().AnErr("error", err).Msg("auto resolve failed")
 		}
 	}
 
-	if err := a.e.HandleEscalation(report); err != nil {
-		return fmt.Errorf("handleReport failed with escalation error: %w", err)
+	if report.Escalated {
+		// the assignee gets escalated reports on top of the usual recipients
+		if names != nil {
+			return a.n.SendNamedNotifications(names, assignee, report)
+		}
+		return a.n.SendAssigneeNotifications(assignee, report)
 	}
 	return a.n.SendNotifications(report)
 }
@@ -113,19 +133,58 @@ func (a *Actions) PostReport(ctx *gin.Context) {
 }
 
 func (a *Actions) GetReports(ctx *gin.Context) {
-	sourceID := general.SourceID{
-		App:      ctx.Request.URL.Query().Get("app"),
-		Instance: ctx.Request.URL.Query().Get("instance"),
-		Tag:      ctx.Request.URL.Query().Get("tag"),
-	}
-	resolved := ctx.Request.URL.Query().Get("resolved") == "true"
-	rdb := engine.NewReportsDatabase(a.db)
-	reports, err := rdb.ListReports(sourceID, resolved)
+	args, err := parseListReportsArgs(ctx, a.loc)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	a.writeReportsPage(ctx, args)
+}
+
+func (a *Actions) Search(ctx *gin.Context) {
+	args, err := parseListReportsArgs(ctx, a.loc)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	// unlike the plain listing, search includes resolved reports by default
+	args.Resolved = ctx.Query("resolved") != "false"
+	args.Search, err = engine.ParseSearchQuery(ctx.Query("q"))
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	a.writeReportsPage(ctx, args)
+}
+
+// writeReportsPage lists reports and writes them as a single page.
+// To keep the response a plain array of reports, the total number
+// of reports and the next page cursor (if any) are sent in headers.
+func (a *Actions) writeReportsPage(ctx *gin.Context, args engine.ListReportsArgs) {
+	total, err := a.store.CountReports(args)
 	if err != nil {
 		uniresp.RespondWithErrorJSON(
 			ctx, err, http.StatusInternalServerError)
 		return
 	}
+	// we ask for one more item to find out whether there is a next page
+	pageSize := args.Limit
+	args.Limit++
+	reports, err := a.store.ListReports(args)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	ctx.Header(totalCountHeader, strconv.Itoa(total))
+	if len(reports) > pageSize {
+		reports = reports[:pageSize]
+		last := reports[pageSize-1]
+		ctx.Header(nextCursorHeader, (&engine.ReportsCursor{Created: last.Created, ID: last.ID}).Encode())
+	}
 	uniresp.WriteJSONResponse(ctx.Writer, reports)
 }
 
@@ -137,14 +196,13 @@ func (a *Actions) ResolveGroup(ctx *gin.Context) {
 			ctx, err, http.StatusBadRequest)
 		return
 	}
-	rdb := engine.NewReportsDatabase(a.db)
-	userID, err := auth.GetUserID(ctx, rdb)
+	userID, err := auth.GetUserID(ctx, a.store)
 	if err != nil {
 		uniresp.RespondWithErrorJSON(
 			ctx, err, http.StatusInternalServerError)
 		return
 	}
-	err = rdb.ResolveGroup(groupID, userID)
+	err = a.store.ResolveGroup(groupID, userID)
 	if err != nil {
 		uniresp.RespondWithErrorJSON(
 			ctx, err, http.StatusInternalServerError)
@@ -166,8 +224,7 @@ func (a *Actions) GetReport(ctx *gin.Context) {
 			ctx, err, http.StatusInternalServerError)
 		return
 	}
-	rdb := engine.NewReportsDatabase(a.db)
-	report, err := rdb.SelectReport(reportID)
+	report, err := a.store.SelectReport(reportID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			uniresp.RespondWithErrorJSON(
@@ -185,8 +242,7 @@ func (a *Actions) GetReport(ctx *gin.Context) {
 }
 
 func (a *Actions) GetSources(ctx *gin.Context) {
-	rdb := engine.NewReportsDatabase(a.db)
-	filters, err := rdb.GetSources()
+	filters, err := a.store.GetSources()
 	if err != nil {
 		if err == sql.ErrNoRows {
 			uniresp.RespondWithErrorJSON(
@@ -201,8 +257,7 @@ func (a *Actions) GetSources(ctx *gin.Context) {
 }
 
 func (a *Actions) GetOverview(ctx *gin.Context) {
-	rdb := engine.NewReportsDatabase(a.db)
-	counts, err := rdb.GetOverview()
+	counts, err := a.store.GetOverview()
 	if err != nil {
 		if err == sql.ErrNoRows {
 			uniresp.RespondWithErrorJSON(
@@ -213,6 +268,9 @@ func (a *Actions) GetOverview(ctx *gin.Context) {
 		}
 		return
 	}
+	for _, count := range counts {
+		count.Rate = a.e.Rate(count.SourceID)
+	}
 	uniresp.WriteJSONResponse(ctx.Writer, counts)
 }
 
@@ -237,12 +295,19 @@ func (a *Actions) Close() {
 	close(a.selfReport)
 }
 
-func NewActions(loc *time.Location, db *sql.DB, n *notifiers.Notifiers, e *escalator.Escalator) *Actions {
+func NewActions(
+	loc *time.Location,
+	store engine.ReportStore,
+	n *notifiers.Notifiers,
+	e *escalator.Escalator,
+	m *maintenance.Schedule,
+) *Actions {
 	actions := &Actions{
 		loc:        loc,
-		db:         db,
+		store:      store,
 		n:          n,
 		e:          e,
+		m:          m,
 		selfReport: make(chan error),
 	}
 	go actions.RunSelfReporter()
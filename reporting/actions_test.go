@@ -0,0 +1,213 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/czcorpus/conomi/engine"
+	"github.com/czcorpus/conomi/escalator"
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/maintenance"
+	"github.com/czcorpus/conomi/notifiers"
+	"github.com/gin-gonic/gin"
+)
+
+// newTestActions creates actions backed by a new SQLite database
+// along with a running escalator
+func newTestActions(t *testing.T, conf *escalator.Conf) (*Actions, engine.ReportStore) {
+	t.Helper()
+	dbConf := &engine.DBConf{Driver: engine.DriverSQLite, Name: filepath.Join(t.TempDir(), "conomi.db")}
+	migrator, err := engine.NewMigrator(dbConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer migrator.Close()
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	store, err := engine.Open(dbConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.ValidateAndDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	n, err := notifiers.NewNotifiers(general.GeneralInfo{}, nil, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := escalator.NewEscalator(conf, store, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.SetSnoozer(e)
+	m, err := maintenance.NewSchedule(store, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.SetMaintenance(m)
+	e.Start()
+	t.Cleanup(e.Stop)
+	a := NewActions(time.UTC, store, n, e, m)
+	t.Cleanup(a.Close)
+	return a, store
+}
+
+// testContext creates a context of a request made by the user 1
+func testContext(method string, body any, params ...gin.Param) (*gin.Context, *httptest.ResponseRecorder) {
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	data, _ := json.Marshal(body)
+	ctx.Request = httptest.NewRequest(method, "/", bytes.NewReader(data))
+	ctx.Params = params
+	ctx.Set("userID", "1")
+	return ctx, recorder
+}
+
+// TestConcurrentPostAndResolve posts reports (including auto-resolving
+// recoveries) while groups are being resolved and escalated sources
+// checked. It is meant to be run with the race detector.
+func TestConcurrentPostAndResolve(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a, store := newTestActions(t, &escalator.Conf{CheckIntervalSecs: 1})
+	// groups of app0 are resolved manually, the other sources
+	// send recoveries resolving their groups automatically
+	severities := []general.SeverityLevel{
+		general.SeverityLevelWarning,
+		general.SeverityLevelCritical,
+		general.SeverityLevelRecovery,
+	}
+	sources := map[string][]general.SeverityLevel{
+		"app0": severities[:2],
+		"app1": severities,
+		"app2": severities,
+	}
+
+	var posting sync.WaitGroup
+	errs := make(chan error, 100)
+	for app, severities := range sources {
+		posting.Add(1)
+		go func(app string, severities []general.SeverityLevel) {
+			defer posting.Done()
+			for j := 0; j < 30; j++ {
+				ctx, recorder := testContext(http.MethodPost, map[string]any{
+					"sourceId": map[string]string{"app": app},
+					"severity": severities[j%len(severities)],
+					"subject":  "test",
+				})
+				a.PostReport(ctx)
+				if recorder.Code != http.StatusOK {
+					errs <- fmt.Errorf("failed to post report: %s", recorder.Body.String())
+					return
+				}
+			}
+		}(app, severities)
+	}
+
+	// groups keep being resolved until the reports are posted
+	// and the escalator ticker has fired at least once
+	stop := make(chan struct{})
+	resolving := make(chan struct{})
+	go func() {
+		defer close(resolving)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			counts, err := store.GetOverview()
+			if err != nil {
+				errs <- err
+				return
+			}
+			for _, count := range counts {
+				if count.SourceID.App != "app0" {
+					continue
+				}
+				ctx, recorder := testContext(http.MethodPost, nil, gin.Param{Key: "groupId", Value: strconv.Itoa(count.GroupID)})
+				a.ResolveGroup(ctx)
+				if recorder.Code != http.StatusOK {
+					errs <- fmt.Errorf("failed to resolve group: %s", recorder.Body.String())
+					return
+				}
+			}
+		}
+	}()
+	posting.Wait()
+	time.Sleep(1100 * time.Millisecond)
+	close(stop)
+	<-resolving
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestGetReportsPageHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a, store := newTestActions(t, &escalator.Conf{})
+	for i := 0; i < 3; i++ {
+		err := store.InsertReport(&general.Report{
+			SourceID:         general.SourceID{App: "app"},
+			Severity:         general.SeverityLevelInfo,
+			Subject:          "test",
+			Created:          time.Now().Add(time.Duration(i) * time.Minute),
+			ResolvedByUserID: -1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	getPage := func(query string) http.Header {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/api/reports?"+query, nil)
+		a.GetReports(ctx)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("failed to get reports: %s", recorder.Body.String())
+		}
+		return recorder.Header()
+	}
+
+	header := getPage("limit=2")
+	if total := header.Get(totalCountHeader); total != "3" {
+		t.Errorf("expected total 3, got %q", total)
+	}
+	cursor := header.Get(nextCursorHeader)
+	if cursor == "" {
+		t.Fatal("expected a next page cursor")
+	}
+	header = getPage("limit=2&cursor=" + url.QueryEscape(cursor))
+	if total := header.Get(totalCountHeader); total != "3" {
+		t.Errorf("expected total 3, got %q", total)
+	}
+	if cursor := header.Get(nextCursorHeader); cursor != "" {
+		t.Errorf("expected no cursor of the last page, got %q", cursor)
+	}
+}
@@ -17,7 +17,10 @@
 package content
 
 import (
+	"io"
+
 	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
 	"github.com/gomarkdown/markdown/html"
 	"github.com/gomarkdown/markdown/parser"
 )
@@ -35,3 +38,33 @@ func MarkdownToHTML(md string) string {
 
 	return string(markdown.Render(doc, renderer))
 }
+
+// escapeRawHTML renders raw HTML found in a document as a plain text
+func escapeRawHTML(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	switch node := node.(type) {
+	case *ast.HTMLSpan:
+		html.EscapeHTML(w, node.Literal)
+		return ast.GoToNext, true
+	case *ast.HTMLBlock:
+		io.WriteString(w, "<p>")
+		html.EscapeHTML(w, node.Literal)
+		io.WriteString(w, "</p>\n")
+		return ast.GoToNext, true
+	}
+	return ast.GoToNext, false
+}
+
+// MarkdownToSafeHTML works like MarkdownToHTML but it is intended
+// for user provided texts (e.g. comments) - raw HTML is escaped
+// and only links to trusted protocols are allowed
+func MarkdownToSafeHTML(md string) string {
+	extensions := parser.CommonExtensions | parser.AutoHeadingIDs | parser.NoEmptyLineBeforeBlock
+	p := parser.NewWithExtensions(extensions)
+	doc := p.Parse([]byte(md))
+
+	htmlFlags := html.CommonFlags | html.HrefTargetBlank | html.Safelink
+	opts := html.RendererOptions{Flags: htmlFlags, RenderNodeHook: escapeRawHTML}
+	renderer := html.NewRenderer(opts)
+
+	return string(markdown.Render(doc, renderer))
+}
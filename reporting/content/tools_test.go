@@ -0,0 +1,49 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownToSafeHTML(t *testing.T) {
+	tests := []struct {
+		name      string
+		md        string
+		contains  string
+		forbidden string
+	}{
+		{"markdown", "**restarted** the `db`", "<strong>restarted</strong>", ""},
+		{"inline html", "see <img src=x onerror=alert(1)>", "&lt;img src=x onerror=alert(1)&gt;", "<img"},
+		{"script block", "<script>alert(1)</script>", "&lt;script&gt;", "<script"},
+		{"html block", "<div onclick=\"alert(1)\">x</div>", "&lt;div", "<div"},
+		{"javascript link", "[click](javascript:alert(1))", "click", "javascript:"},
+		{"https link", "[docs](https://example.com)", `href="https://example.com"`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MarkdownToSafeHTML(tt.md)
+			if !strings.Contains(got, tt.contains) {
+				t.Errorf("MarkdownToSafeHTML(%q) = %q, expected to contain %q", tt.md, got, tt.contains)
+			}
+			if tt.forbidden != "" && strings.Contains(got, tt.forbidden) {
+				t.Errorf("MarkdownToSafeHTML(%q) = %q, must not contain %q", tt.md, got, tt.forbidden)
+			}
+		})
+	}
+}
@@ -0,0 +1,413 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporting
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/czcorpus/conomi/auth"
+	"github.com/czcorpus/conomi/engine"
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/reporting/content"
+	"github.com/gin-gonic/gin"
+)
+
+func (a *Actions) GetGroups(ctx *gin.Context) {
+	args := engine.ListGroupsArgs{
+		SourceID: general.SourceID{
+			App:      ctx.Query("app"),
+			Instance: ctx.Query("instance"),
+			Tag:      ctx.Query("tag"),
+		},
+		Resolved:  ctx.Query("resolved") == "true",
+		Escalated: ctx.Query("escalated") == "true",
+		Limit:     dfltReportsPageSize,
+	}
+	var err error
+	if ctx.Query("mine") == "true" {
+		args.AssignedTo, err = auth.GetUserID(ctx, a.store)
+		if err != nil {
+			uniresp.RespondWithErrorJSON(
+				ctx, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	if limit := ctx.Query("limit"); limit != "" {
+		args.Limit, err = strconv.Atoi(limit)
+		if err != nil || args.Limit <= 0 || args.Limit > maxReportsPageSize {
+			uniresp.RespondWithErrorJSON(
+				ctx, fmt.Errorf("limit must be between 1 and %d", maxReportsPageSize), http.StatusBadRequest)
+			return
+		}
+	}
+	if offset := ctx.Query("offset"); offset != "" {
+		args.Offset, err = strconv.Atoi(offset)
+		if err != nil || args.Offset < 0 {
+			uniresp.RespondWithErrorJSON(
+				ctx, fmt.Errorf("offset must be a non-negative number"), http.StatusBadRequest)
+			return
+		}
+	}
+	total, err := a.store.CountGroups(args)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	groups, err := a.store.ListGroups(args)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, general.ReportGroupsPage{Groups: groups, Total: total})
+}
+
+func (a *Actions) GetGroup(ctx *gin.Context) {
+	groupID, err := strconv.Atoi(ctx.Param("groupId"))
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	group, err := a.store.SelectGroup(groupID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			uniresp.RespondWithErrorJSON(
+				ctx, err, http.StatusNotFound)
+		} else {
+			uniresp.RespondWithErrorJSON(
+				ctx, err, http.StatusInternalServerError)
+		}
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, group)
+}
+
+func (a *Actions) GetGroupReports(ctx *gin.Context) {
+	groupID, err := strconv.Atoi(ctx.Param("groupId"))
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	args, err := parseListReportsArgs(ctx, a.loc)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	args.GroupID = groupID
+	args.Resolved = true
+	a.writeReportsPage(ctx, args)
+}
+
+type mergeGroupsArgs struct {
+	GroupIDs []int `json:"groupIds"`
+}
+
+type assignGroupArgs struct {
+	// User is a name of the assignee. If empty,
+	// the group is assigned to the current user.
+	User string `json:"user"`
+}
+
+type groupCommentArgs struct {
+	Body string `json:"body"`
+}
+
+type splitGroupArgs struct {
+	ReportIDs []int `json:"reportIds"`
+}
+
+func (a *Actions) respondGroupOperationError(ctx *gin.Context, err error) {
+	if errors.Is(err, engine.ErrInvalidGroupOperation) {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusConflict)
+	} else {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+	}
+}
+
+func (a *Actions) ReopenGroup(ctx *gin.Context) {
+	groupID, err := strconv.Atoi(ctx.Param("groupId"))
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	userID, err := auth.GetUserID(ctx, a.store)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	if err := a.store.ReopenGroup(groupID, userID); err != nil {
+		a.respondGroupOperationError(ctx, err)
+		return
+	}
+	if err := a.e.Reload(); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+}
+
+func (a *Actions) MergeGroups(ctx *gin.Context) {
+	groupID, err := strconv.Atoi(ctx.Param("groupId"))
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	var args mergeGroupsArgs
+	if err := ctx.ShouldBindJSON(&args); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	if len(args.GroupIDs) == 0 {
+		uniresp.RespondWithErrorJSON(
+			ctx, fmt.Errorf("no groups to merge"), http.StatusBadRequest)
+		return
+	}
+	userID, err := auth.GetUserID(ctx, a.store)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	if err := a.store.MergeGroups(groupID, args.GroupIDs, userID); err != nil {
+		a.respondGroupOperationError(ctx, err)
+		return
+	}
+	if err := a.e.Reload(); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+}
+
+func (a *Actions) SplitGroup(ctx *gin.Context) {
+	groupID, err := strconv.Atoi(ctx.Param("groupId"))
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	var args splitGroupArgs
+	if err := ctx.ShouldBindJSON(&args); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	if len(args.ReportIDs) == 0 {
+		uniresp.RespondWithErrorJSON(
+			ctx, fmt.Errorf("no reports to split"), http.StatusBadRequest)
+		return
+	}
+	userID, err := auth.GetUserID(ctx, a.store)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	newGroupID, err := a.store.SplitGroup(groupID, args.ReportIDs, userID)
+	if err != nil {
+		a.respondGroupOperationError(ctx, err)
+		return
+	}
+	if err := a.e.Reload(); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"groupId": newGroupID})
+}
+
+func (a *Actions) AcknowledgeGroup(ctx *gin.Context) {
+	groupID, err := strconv.Atoi(ctx.Param("groupId"))
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	userID, err := auth.GetUserID(ctx, a.store)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	if err := a.store.AcknowledgeGroup(groupID, userID); err != nil {
+		a.respondGroupOperationError(ctx, err)
+		return
+	}
+	if err := a.e.Reload(); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+}
+
+func (a *Actions) AssignGroup(ctx *gin.Context) {
+	groupID, err := strconv.Atoi(ctx.Param("groupId"))
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	var args assignGroupArgs
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&args); err != nil {
+			uniresp.RespondWithErrorJSON(
+				ctx, err, http.StatusBadRequest)
+			return
+		}
+	}
+	userID, err := auth.GetUserID(ctx, a.store)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	// users assign groups to themselves by default
+	assigneeID := userID
+	if args.User != "" {
+		assigneeID, err = a.store.GetUserID(args.User)
+		if err != nil {
+			uniresp.RespondWithErrorJSON(
+				ctx, err, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := a.store.AssignGroup(groupID, assigneeID, userID); err != nil {
+		a.respondGroupOperationError(ctx, err)
+		return
+	}
+	if err := a.e.Reload(); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+}
+
+func (a *Actions) UnassignGroup(ctx *gin.Context) {
+	groupID, err := strconv.Atoi(ctx.Param("groupId"))
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	userID, err := auth.GetUserID(ctx, a.store)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	if err := a.store.AssignGroup(groupID, -1, userID); err != nil {
+		a.respondGroupOperationError(ctx, err)
+		return
+	}
+	if err := a.e.Reload(); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+}
+
+func (a *Actions) GetGroupEvents(ctx *gin.Context) {
+	groupID, err := strconv.Atoi(ctx.Param("groupId"))
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	events, err := a.store.ListGroupEvents(groupID)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	if ctx.Query("md-to-html") == "1" {
+		for _, event := range events {
+			event.Body = content.MarkdownToSafeHTML(event.Body)
+		}
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, events)
+}
+
+func (a *Actions) PostGroupComment(ctx *gin.Context) {
+	groupID, err := strconv.Atoi(ctx.Param("groupId"))
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	var args groupCommentArgs
+	if err := ctx.ShouldBindJSON(&args); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(args.Body) == "" {
+		uniresp.RespondWithErrorJSON(
+			ctx, fmt.Errorf("empty comment"), http.StatusBadRequest)
+		return
+	}
+	userID, err := auth.GetUserID(ctx, a.store)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	if _, err := a.store.SelectGroup(groupID); err != nil {
+		if err == sql.ErrNoRows {
+			uniresp.RespondWithErrorJSON(
+				ctx, err, http.StatusNotFound)
+		} else {
+			uniresp.RespondWithErrorJSON(
+				ctx, err, http.StatusInternalServerError)
+		}
+		return
+	}
+	event := general.GroupEvent{
+		GroupID: groupID,
+		Type:    general.GroupEventComment,
+		UserID:  userID,
+		Body:    args.Body,
+		Created: time.Now().In(a.loc),
+	}
+	if err := a.store.AddGroupEvent(&event); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, event)
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporting
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/czcorpus/conomi/auth"
+	"github.com/czcorpus/conomi/general"
+	"github.com/czcorpus/conomi/maintenance"
+	"github.com/gin-gonic/gin"
+)
+
+func (a *Actions) GetMaintenanceWindows(ctx *gin.Context) {
+	windows, err := a.store.ListMaintenanceWindows()
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, windows)
+}
+
+func (a *Actions) PostMaintenanceWindow(ctx *gin.Context) {
+	window := general.MaintenanceWindow{Created: time.Now().In(a.loc)}
+	if err := ctx.ShouldBindJSON(&window); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	if err := maintenance.Validate(&window); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	userID, err := auth.GetUserID(ctx, a.store)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	window.UserID = userID
+	if err := a.store.InsertMaintenanceWindow(&window); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	if err := a.m.Reload(); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, window)
+}
+
+func (a *Actions) DeleteMaintenanceWindow(ctx *gin.Context) {
+	windowID, err := strconv.Atoi(ctx.Param("windowId"))
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	if err := a.store.DeleteMaintenanceWindow(windowID); err != nil {
+		if err == sql.ErrNoRows {
+			uniresp.RespondWithErrorJSON(
+				ctx, err, http.StatusNotFound)
+		} else {
+			uniresp.RespondWithErrorJSON(
+				ctx, err, http.StatusInternalServerError)
+		}
+		return
+	}
+	if err := a.m.Reload(); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+}
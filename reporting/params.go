@@ -0,0 +1,113 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporting
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/czcorpus/conomi/engine"
+	"github.com/czcorpus/conomi/general"
+	"github.com/gin-gonic/gin"
+)
+
+// parseTimeParam accepts either RFC3339 datetime
+// or a date (in the `loc` time zone)
+func parseTimeParam(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time value `%s`, use RFC3339 or YYYY-MM-DD", value)
+	}
+	return t, nil
+}
+
+// parseTimeLimit works like parseTimeParam but a date is understood
+// as the whole day. In such case, the next midnight is returned along
+// with `exclusive` set to true.
+func parseTimeLimit(value string, loc *time.Location) (t time.Time, exclusive bool, err error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err = parseTimeParam(value, loc)
+	if err != nil {
+		return t, false, err
+	}
+	return t.AddDate(0, 0, 1), true, nil
+}
+
+// parseListReportsArgs reads report listing filter and paging
+// from URL query
+func parseListReportsArgs(ctx *gin.Context, loc *time.Location) (engine.ListReportsArgs, error) {
+	query := ctx.Request.URL.Query()
+	args := engine.ListReportsArgs{
+		SourceID: general.SourceID{
+			App:      query.Get("app"),
+			Instance: query.Get("instance"),
+			Tag:      query.Get("tag"),
+		},
+		Resolved:   query.Get("resolved") == "true",
+		Escalated:  query.Get("escalated") == "true",
+		ResolvedBy: query.Get("resolvedBy"),
+		Subject:    query.Get("subject"),
+		Order:      query.Get("order"),
+		Limit:      dfltReportsPageSize,
+	}
+	// severities can be passed both as repeated
+	// and comma separated values
+	for _, value := range query["severity"] {
+		for _, severity := range strings.Split(value, ",") {
+			if severity != "" {
+				args.Severities = append(args.Severities, general.SeverityLevel(severity))
+			}
+		}
+	}
+	var err error
+	if from := query.Get("from"); from != "" {
+		if args.From, err = parseTimeParam(from, loc); err != nil {
+			return args, err
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if args.To, args.ToExclusive, err = parseTimeLimit(to, loc); err != nil {
+			return args, err
+		}
+	}
+	if groupID := query.Get("groupId"); groupID != "" {
+		if args.GroupID, err = strconv.Atoi(groupID); err != nil {
+			return args, fmt.Errorf("invalid group ID: %w", err)
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if args.Limit, err = strconv.Atoi(limit); err != nil {
+			return args, fmt.Errorf("invalid limit: %w", err)
+		}
+		if args.Limit <= 0 || args.Limit > maxReportsPageSize {
+			return args, fmt.Errorf("limit must be between 1 and %d", maxReportsPageSize)
+		}
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		if args.Cursor, err = engine.DecodeReportsCursor(cursor); err != nil {
+			return args, err
+		}
+	}
+	return args, args.Validate()
+}
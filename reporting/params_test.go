@@ -0,0 +1,49 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporting
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeLimit(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	tests := []struct {
+		name          string
+		value         string
+		want          time.Time
+		wantExclusive bool
+		wantErr       bool
+	}{
+		{"datetime", "2024-03-01T12:30:00Z", time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), false, false},
+		{"date", "2024-03-01", time.Date(2024, 3, 2, 0, 0, 0, 0, loc), true, false},
+		{"end of month", "2024-02-29", time.Date(2024, 3, 1, 0, 0, 0, 0, loc), true, false},
+		{"invalid", "yesterday", time.Time{}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, exclusive, err := parseTimeLimit(tt.value, loc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimeLimit(%q) error = %v, wantErr %t", tt.value, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) || exclusive != tt.wantExclusive {
+				t.Errorf("parseTimeLimit(%q) = %v, %t, want %v, %t", tt.value, got, exclusive, tt.want, tt.wantExclusive)
+			}
+		})
+	}
+}
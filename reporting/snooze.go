@@ -0,0 +1,153 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporting
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/czcorpus/conomi/auth"
+	"github.com/czcorpus/conomi/general"
+	"github.com/gin-gonic/gin"
+)
+
+func (a *Actions) parseSnoozeUntil(ctx *gin.Context) (time.Time, error) {
+	value := ctx.Query("until")
+	if value == "" {
+		return time.Time{}, fmt.Errorf("missing `until` parameter")
+	}
+	until, err := parseTimeParam(value, a.loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !until.After(time.Now()) {
+		return time.Time{}, fmt.Errorf("`until` must be in the future")
+	}
+	return until, nil
+}
+
+func (a *Actions) snoozeGroup(ctx *gin.Context, until time.Time) {
+	groupID, err := strconv.Atoi(ctx.Param("groupId"))
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	userID, err := auth.GetUserID(ctx, a.store)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	if err := a.store.SnoozeGroup(groupID, until, userID); err != nil {
+		a.respondGroupOperationError(ctx, err)
+		return
+	}
+	if err := a.e.Reload(); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+}
+
+func (a *Actions) SnoozeGroup(ctx *gin.Context) {
+	until, err := a.parseSnoozeUntil(ctx)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	a.snoozeGroup(ctx, until)
+}
+
+func (a *Actions) UnsnoozeGroup(ctx *gin.Context) {
+	a.snoozeGroup(ctx, time.Time{})
+}
+
+func (a *Actions) SnoozeSource(ctx *gin.Context) {
+	sourceID := general.SourceID{
+		App:      ctx.Query("app"),
+		Instance: ctx.Query("instance"),
+		Tag:      ctx.Query("tag"),
+	}
+	if sourceID.App == "" {
+		uniresp.RespondWithErrorJSON(
+			ctx, fmt.Errorf("missing `app` parameter"), http.StatusBadRequest)
+		return
+	}
+	until, err := a.parseSnoozeUntil(ctx)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusBadRequest)
+		return
+	}
+	userID, err := auth.GetUserID(ctx, a.store)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	if err := a.store.SnoozeSource(sourceID, until, userID); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	if err := a.e.Reload(); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+}
+
+func (a *Actions) UnsnoozeSource(ctx *gin.Context) {
+	sourceID := general.SourceID{
+		App:      ctx.Query("app"),
+		Instance: ctx.Query("instance"),
+		Tag:      ctx.Query("tag"),
+	}
+	if sourceID.App == "" {
+		uniresp.RespondWithErrorJSON(
+			ctx, fmt.Errorf("missing `app` parameter"), http.StatusBadRequest)
+		return
+	}
+	if err := a.store.UnsnoozeSource(sourceID); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	if err := a.e.Reload(); err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+}
+
+func (a *Actions) GetSourceSnoozes(ctx *gin.Context) {
+	snoozes, err := a.store.ListSourceSnoozes()
+	if err != nil {
+		uniresp.RespondWithErrorJSON(
+			ctx, err, http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, snoozes)
+}
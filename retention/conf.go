@@ -0,0 +1,76 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retention
+
+import (
+	"fmt"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+const (
+	dfltBatchSize         = 1000
+	dfltCheckIntervalSecs = 3600
+)
+
+type Conf struct {
+	// MaxAgeDays specifies for each severity level how many days
+	// reports are kept. Severities not listed here are kept forever.
+	MaxAgeDays map[general.SeverityLevel]int `json:"maxAgeDays"`
+
+	// MaxReportsPerGroup limits number of (newest) reports kept
+	// in a group. Zero means no limit.
+	MaxReportsPerGroup int `json:"maxReportsPerGroup"`
+
+	// KeepUnresolved protects reports of unresolved groups
+	// from being purged
+	KeepUnresolved bool `json:"keepUnresolved"`
+
+	// Archive moves purged reports to the `conomi_report_archive` table
+	// instead of deleting them
+	Archive bool `json:"archive"`
+
+	BatchSize         int `json:"batchSize"`
+	CheckIntervalSecs int `json:"checkIntervalSecs"`
+}
+
+func (conf *Conf) ValidateAndDefaults() error {
+	for severity, days := range conf.MaxAgeDays {
+		if err := severity.Validate(); err != nil {
+			return fmt.Errorf("invalid retention conf: %w", err)
+		}
+		if days <= 0 {
+			return fmt.Errorf("invalid retention conf: maxAgeDays of %s must be positive", severity)
+		}
+	}
+	if conf.MaxReportsPerGroup < 0 {
+		return fmt.Errorf("invalid retention conf: maxReportsPerGroup cannot be negative")
+	}
+	if conf.BatchSize < 0 {
+		return fmt.Errorf("invalid retention conf: batchSize cannot be negative")
+	}
+	if conf.CheckIntervalSecs < 0 {
+		return fmt.Errorf("invalid retention conf: checkIntervalSecs cannot be negative")
+	}
+	if conf.BatchSize == 0 {
+		conf.BatchSize = dfltBatchSize
+	}
+	if conf.CheckIntervalSecs == 0 {
+		conf.CheckIntervalSecs = dfltCheckIntervalSecs
+	}
+	return nil
+}
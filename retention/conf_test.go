@@ -0,0 +1,50 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retention
+
+import (
+	"testing"
+
+	"github.com/czcorpus/conomi/general"
+)
+
+func TestConfValidateAndDefaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    Conf
+		wantErr bool
+	}{
+		{"empty", Conf{}, false},
+		{"max age", Conf{MaxAgeDays: map[general.SeverityLevel]int{general.SeverityLevelInfo: 7}}, false},
+		{"zero max age", Conf{MaxAgeDays: map[general.SeverityLevel]int{general.SeverityLevelInfo: 0}}, true},
+		{"unknown severity", Conf{MaxAgeDays: map[general.SeverityLevel]int{"foo": 7}}, true},
+		{"negative max reports", Conf{MaxReportsPerGroup: -1}, true},
+		{"negative batch size", Conf{BatchSize: -1}, true},
+		{"negative check interval", Conf{CheckIntervalSecs: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.conf.ValidateAndDefaults()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateAndDefaults() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err == nil && (tt.conf.BatchSize <= 0 || tt.conf.CheckIntervalSecs <= 0) {
+				t.Errorf("defaults not applied: %+v", tt.conf)
+			}
+		})
+	}
+}
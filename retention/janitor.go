@@ -0,0 +1,177 @@
+// Copyright 2023 Martin Zimandl <martin.zimandl@gmail.com>
+// Copyright 2023 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retention
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/czcorpus/conomi/engine"
+	"github.com/rs/zerolog/log"
+)
+
+// Reloader is an entity which has to be notified
+// in case purged reports affect its state
+type Reloader interface {
+	Reload() error
+}
+
+type PurgeResult struct {
+	DeletedReports int
+	DeletedGroups  int
+}
+
+// Janitor removes (or archives) reports and report groups
+// based on a configured retention policy
+type Janitor struct {
+	conf     *Conf
+	store    engine.ReportStore
+	loc      *time.Location
+	reloader Reloader
+	done     chan struct{}
+}
+
+// purgeBatches repeatedly calls `find` and removes found reports
+// until there is nothing left. In the dry run mode, reports are
+// just counted. As a report may be matched by more than one rule
+// then, the `counted` set prevents counting it repeatedly.
+func (j *Janitor) purgeBatches(
+	dryRun bool,
+	counted map[int]struct{},
+	find func(offset int) ([]int, error),
+) (int, error) {
+	var total, offset int
+	for {
+		ids, err := find(offset)
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+		if dryRun {
+			offset += len(ids)
+			for _, id := range ids {
+				if _, ok := counted[id]; !ok {
+					counted[id] = struct{}{}
+					total++
+				}
+			}
+
+		} else {
+			if err := j.store.DeleteReports(ids, j.conf.Archive); err != nil {
+				return total, err
+			}
+			total += len(ids)
+		}
+	}
+}
+
+func (j *Janitor) Purge(dryRun bool) (*PurgeResult, error) {
+	ans := &PurgeResult{}
+	now := time.Now().In(j.loc)
+	counted := make(map[int]struct{})
+	for severity, days := range j.conf.MaxAgeDays {
+		olderThan := now.AddDate(0, 0, -days)
+		deleted, err := j.purgeBatches(dryRun, counted, func(offset int) ([]int, error) {
+			return j.store.FindExpiredReports(
+				severity, olderThan, j.conf.KeepUnresolved, offset, j.conf.BatchSize)
+		})
+		ans.DeletedReports += deleted
+		if err != nil {
+			return ans, fmt.Errorf("failed to purge reports: %w", err)
+		}
+	}
+	if j.conf.MaxReportsPerGroup > 0 {
+		groups, err := j.store.FindOversizedGroups(j.conf.MaxReportsPerGroup, j.conf.KeepUnresolved)
+		if err != nil {
+			return ans, fmt.Errorf("failed to purge reports: %w", err)
+		}
+		for _, groupID := range groups {
+			deleted, err := j.purgeBatches(dryRun, counted, func(offset int) ([]int, error) {
+				return j.store.FindExcessReports(
+					groupID, j.conf.MaxReportsPerGroup, offset, j.conf.BatchSize)
+			})
+			ans.DeletedReports += deleted
+			if err != nil {
+				return ans, fmt.Errorf("failed to purge reports: %w", err)
+			}
+		}
+	}
+	if dryRun {
+		reportIDs := make([]int, 0, len(counted))
+		for id := range counted {
+			reportIDs = append(reportIDs, id)
+		}
+		emptied, err := j.store.CountEmptiedGroups(reportIDs)
+		if err != nil {
+			return ans, fmt.Errorf("failed to purge reports: %w", err)
+		}
+		ans.DeletedGroups = emptied
+		return ans, nil
+	}
+	deleted, err := j.store.DeleteEmptyGroups()
+	if err != nil {
+		return ans, fmt.Errorf("failed to purge reports: %w", err)
+	}
+	ans.DeletedGroups = deleted
+	if ans.DeletedReports > 0 && !j.conf.KeepUnresolved && j.reloader != nil {
+		if err := j.reloader.Reload(); err != nil {
+			return ans, fmt.Errorf("failed to purge reports: %w", err)
+		}
+	}
+	return ans, nil
+}
+
+// Start runs the purging periodically in a separate goroutine
+func (j *Janitor) Start() {
+	ticker := time.NewTicker(time.Duration(j.conf.CheckIntervalSecs) * time.Second)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-j.done:
+				return
+			case <-ticker.C:
+				result, err := j.Purge(false)
+				if err != nil {
+					log.Error().Err(err).Msg("janitor failed to purge reports")
+					continue
+				}
+				log.Info().
+					Int("reports", result.DeletedReports).
+					Int("groups", result.DeletedGroups).
+					Bool("archived", j.conf.Archive).
+					Msg("janitor purged old reports")
+			}
+		}
+	}()
+}
+
+func (j *Janitor) Stop() {
+	close(j.done)
+}
+
+func NewJanitor(conf *Conf, store engine.ReportStore, loc *time.Location, reloader Reloader) *Janitor {
+	return &Janitor{
+		conf:     conf,
+		store:    store,
+		loc:      loc,
+		reloader: reloader,
+		done:     make(chan struct{}),
+	}
+}
@@ -17,6 +17,7 @@
 package templates
 
 import (
+	"encoding/json"
 	"path/filepath"
 	"strings"
 	"text/template"
@@ -24,10 +25,56 @@ import (
 	"github.com/czcorpus/conomi/general"
 )
 
+var (
+	markdownV2Escaper = strings.NewReplacer(
+		"\\", "\\\\", "_", "\\_", "*", "\\*", "[", "\\[", "]", "\\]", "(", "\\(", ")", "\\)",
+		"~", "\\~", "`", "\\`", ">", "\\>", "#", "\\#", "+", "\\+", "-", "\\-", "=", "\\=",
+		"|", "\\|", "{", "\\{", "}", "\\}", ".", "\\.", "!", "\\!",
+	)
+	markdownV2URLEscaper = strings.NewReplacer("\\", "\\\\", ")", "\\)")
+)
+
 type NotificationTemplateData struct {
 	NotifierName string
 	Info         general.GeneralInfo
 	Report       general.Report
+
+	// Reminder is set in case the notification reminds
+	// of an unacknowledged escalation (see Report.Reminder)
+	Reminder bool
+}
+
+// MkReportSourceIDLabel creates a human readable label
+// of the report source (e.g. `app/instance[tag]`)
+func MkReportSourceIDLabel(report general.Report) string {
+	var ans strings.Builder
+	ans.WriteString(report.SourceID.App)
+	if report.SourceID.Instance != "" {
+		ans.WriteString("/" + report.SourceID.Instance)
+	}
+	if report.SourceID.Tag != "" {
+		ans.WriteString("[" + report.SourceID.Tag + "]")
+	}
+	return ans.String()
+}
+
+// EscapeMarkdownV2 escapes all the characters with a special
+// meaning in Telegram MarkdownV2 formatting
+func EscapeMarkdownV2(text string) string {
+	return markdownV2Escaper.Replace(text)
+}
+
+// EscapeMarkdownV2URL escapes characters with a special meaning
+// within the URL part of a Telegram MarkdownV2 link
+func EscapeMarkdownV2URL(text string) string {
+	return markdownV2URLEscaper.Replace(text)
+}
+
+// EscapeJSON encodes the value as a JSON value (e.g. a quoted
+// and escaped string) to be used within JSON templates
+func EscapeJSON(value any) (string, error) {
+	ans, err := json.Marshal(value)
+	return string(ans), err
 }
 
 func GetTemplate(absPath string) (*template.Template, error) {
@@ -45,17 +92,10 @@ func GetTemplate(absPath string) (*template.Template, error) {
 			}
 			return ":orange_circle:"
 		},
-		"mkReportSourceIDLabel": func(report general.Report) string {
-			var ans strings.Builder
-			ans.WriteString(report.SourceID.App)
-			if report.SourceID.Instance != "" {
-				ans.WriteString("/" + report.SourceID.Instance)
-			}
-			if report.SourceID.Tag != "" {
-				ans.WriteString("[" + report.SourceID.Tag + "]")
-			}
-			return ans.String()
-		},
+		"mkReportSourceIDLabel": MkReportSourceIDLabel,
+		"escapeMarkdownV2":      EscapeMarkdownV2,
+		"escapeMarkdownV2URL":   EscapeMarkdownV2URL,
+		"json":                  EscapeJSON,
 	}
 	return template.New(filepath.Base(absPath)).Funcs(templateFunc).ParseFiles(absPath)
 }